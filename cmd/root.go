@@ -12,14 +12,25 @@ func main() {
 		Use:   "artifact-cli",
 		Short: "A CLI tool to push, pull, and sync folders as OCI artifacts",
 		Long:  `A command-line interface to package local folders, push them as OCI artifacts to a registry, pull them back down, and sync multiple artifacts based on configuration.`,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			verbose, _ := cmd.Flags().GetBool("verbose")
 			utils.SetVerbose(verbose)
+
+			// Supported platforms can be configured with a flag or an environment variable
+			supportedPlatforms, _ := cmd.Flags().GetStringSlice("supported-platforms")
+			if len(supportedPlatforms) == 0 {
+				supportedPlatforms = utils.SupportedPlatformsFromEnv()
+			}
+			if len(supportedPlatforms) > 0 {
+				return utils.SetSupportedPlatforms(supportedPlatforms)
+			}
+			return nil
 		},
 	}
 
 	// Add persistent flags
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().StringSlice("supported-platforms", nil, "Comma-separated list of the platforms accepted by push and pull (can also use "+utils.SupportedPlatformsEnv+" env var). Defaults to the common linux, darwin and windows platforms")
 
 	rootCmd.AddCommand(cmd.NewPushCmd())
 	rootCmd.AddCommand(cmd.NewPullCmd())
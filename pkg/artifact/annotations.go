@@ -0,0 +1,142 @@
+package artifact
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gopkg.in/yaml.v3"
+
+	"educates-artifact-cli/pkg/utils"
+)
+
+// Annotations used to identify artifacts generated by artifact-cli
+const (
+	AnnotationTool         = "dev.educates.artifact-cli.tool"
+	AnnotationVersion      = "dev.educates.artifact-cli.version"
+	AnnotationArtifactType = "dev.educates.artifact-cli.artifact-type"
+
+	// Sub-path of the folder packaged in a layer, '.' for the layer holding the rest of the folder
+	AnnotationLayerPath = "dev.educates.artifact-cli.layer.path"
+
+	// Platform of the manifest. Not added on OCI index metadata
+	AnnotationPlatform = "org.opencontainers.image.platform"
+
+	// Title of the manifests pushed by artifact-cli. On a layer, the path of the file it holds, as used by 'oras push'
+	AnnotationTitle       = "org.opencontainers.image.title"
+	AnnotationDescription = "org.opencontainers.image.description"
+
+	// Standard annotations filled in on push, from the git repository holding the pushed folder when available
+	AnnotationCreated    = ocispec.AnnotationCreated
+	AnnotationSource     = ocispec.AnnotationSource
+	AnnotationRevision   = ocispec.AnnotationRevision
+	AnnotationOCIVersion = ocispec.AnnotationVersion
+
+	// Annotation set to "true" by 'oras push' on the layers holding a directory: a tarball whose entries
+	// are prefixed with the directory name (the layer title), to be extracted instead of written as a file
+	AnnotationORASUnpack = "io.deis.oras.content.unpack"
+
+	// Annotation set by docker buildx on the attestation manifests it adds to an index, with the
+	// DockerAttestationManifest value. These manifests have no filesystem content.
+	AnnotationDockerReferenceType = "vnd.docker.reference.type"
+	DockerAttestationManifest     = "attestation-manifest"
+
+	// Image config label set by imgpkg on bundles, i.e. images whose folder holds a .imgpkg directory
+	ImgpkgBundleLabel = "dev.carvel.imgpkg.bundle"
+)
+
+// ToolName is the value of the AnnotationTool annotation on artifacts generated by artifact-cli
+const ToolName = "artifact-cli"
+
+// ArtifactCliVersion is the version of artifact-cli recorded in the artifact annotations.
+// It is set at build time via -ldflags.
+var ArtifactCliVersion = "dev"
+
+// ToolAnnotations returns the annotations that identify an artifact generated by artifact-cli
+func ToolAnnotations(artifactType ArtifactType) map[string]string {
+	return map[string]string{
+		AnnotationTool:         ToolName,
+		AnnotationVersion:      ArtifactCliVersion,
+		AnnotationArtifactType: string(artifactType),
+	}
+}
+
+// toolAnnotationPrefix is the prefix of the annotations identifying the artifacts, which users cannot set
+const toolAnnotationPrefix = "dev.educates.artifact-cli."
+
+// StandardAnnotations returns the standard annotations of an artifact pushed from the folder: its creation
+// time when known, and the source URL, revision and version of the git repository holding the folder. The
+// version is the git tag of the checked out commit, or else the first semantic version of the pushed tags.
+func StandardAnnotations(folder string, created *time.Time, tags []string) map[string]string {
+	annotations := make(map[string]string)
+	if created != nil {
+		annotations[AnnotationCreated] = created.UTC().Format(time.RFC3339)
+	}
+
+	if folder != "" {
+		if info, ok := utils.ReadGitInfo(folder); ok {
+			annotations[AnnotationRevision] = info.Revision
+			if info.Source != "" {
+				annotations[AnnotationSource] = info.Source
+			}
+			if info.Tag != "" {
+				annotations[AnnotationOCIVersion] = info.Tag
+			}
+		}
+	}
+	if _, ok := annotations[AnnotationOCIVersion]; !ok {
+		for _, tag := range tags {
+			if semverTagPattern.MatchString(tag) {
+				annotations[AnnotationOCIVersion] = tag
+				break
+			}
+		}
+	}
+	return annotations
+}
+
+// ParseAnnotations returns the annotations read from the annotation file, a YAML or JSON map of strings,
+// overridden by the 'key=value' values. The annotations identifying artifact-cli artifacts cannot be set.
+func ParseAnnotations(values []string, file string) (map[string]string, error) {
+	annotations := make(map[string]string)
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read annotation file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &annotations); err != nil {
+			return nil, fmt.Errorf("failed to parse annotation file %s, expected a map of strings: %w", file, err)
+		}
+	}
+
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid annotation %q, expected key=value", value)
+		}
+		annotations[strings.TrimSpace(key)] = val
+	}
+
+	for key := range annotations {
+		if key == "" {
+			return nil, fmt.Errorf("invalid annotation with an empty key")
+		}
+		if strings.HasPrefix(key, toolAnnotationPrefix) {
+			return nil, fmt.Errorf("annotation %s identifies artifact-cli artifacts and cannot be set", key)
+		}
+	}
+	return annotations, nil
+}
+
+// MergeAnnotations returns a new map holding the annotations of every map, the later ones overriding the
+// earlier ones
+func MergeAnnotations(annotations ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, m := range annotations {
+		maps.Copy(merged, m)
+	}
+	return merged
+}
@@ -3,6 +3,6 @@ package artifact
 import "context"
 
 type Artifact interface {
-	Push(ctx context.Context) error
+	Push(ctx context.Context) (*PushResult, error)
 	Pull(ctx context.Context) error
 }
@@ -0,0 +1,53 @@
+package artifact
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ArtifactType identifies the format used to lay out an artifact in the registry.
+// It implements the pflag.Value interface so it can be used directly as a command flag.
+type ArtifactType string
+
+const (
+	ArtifactTypeOci      ArtifactType = "oci"
+	ArtifactTypeImgpkg   ArtifactType = "imgpkg"
+	ArtifactTypeEducates ArtifactType = "educates"
+)
+
+// SupportedArtifactTypes lists every artifact type that can be selected with --as
+var SupportedArtifactTypes = []ArtifactType{
+	ArtifactTypeOci,
+	ArtifactTypeImgpkg,
+	ArtifactTypeEducates,
+}
+
+// String returns the string representation of the artifact type
+func (t *ArtifactType) String() string {
+	return string(*t)
+}
+
+// Set parses and validates the artifact type from a flag value
+func (t *ArtifactType) Set(value string) error {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, supported := range SupportedArtifactTypes {
+		if value == string(supported) {
+			*t = supported
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported artifact type: %s (supported: %s)", value, joinArtifactTypes(SupportedArtifactTypes))
+}
+
+// Type returns the type name shown in the command help
+func (t *ArtifactType) Type() string {
+	return "artifactType"
+}
+
+func joinArtifactTypes(types []ArtifactType) string {
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		names = append(names, string(t))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,147 @@
+package artifact
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+
+	"educates-artifact-cli/pkg/utils"
+)
+
+// ArtifactConfig is the config blob of the manifests pushed by artifact-cli. The fields it shares with the
+// OCI image config (created, architecture, os, variant, os.version, rootfs) use the same layout, so that it
+// is a valid image config and generic tools can read them.
+type ArtifactConfig struct {
+	Created      *time.Time `json:"created,omitempty"`
+	Architecture string     `json:"architecture,omitempty"`
+	OS           string     `json:"os,omitempty"`
+	Variant      string     `json:"variant,omitempty"`
+	OSVersion    string     `json:"os.version,omitempty"`
+	// Uncompressed digests of the layers, required by image configs. Nil for the configs of artifact-cli
+	// versions that did not record them
+	RootFS *ocispec.RootFS `json:"rootfs,omitempty"`
+	// Metadata of the packaged folder. Nil for configs not generated by artifact-cli
+	Artifact *ArtifactMetadata `json:"artifact,omitempty"`
+}
+
+// ArtifactMetadata describes the folder packaged in an artifact, so that it can be inspected
+// without downloading its layers
+type ArtifactMetadata struct {
+	Tool        string `json:"tool"`
+	ToolVersion string `json:"toolVersion"`
+	// Name of the pushed folder, or comma separated names when several folders are merged
+	SourceFolder string `json:"sourceFolder"`
+	// Number of regular files in the folder
+	FileCount int `json:"fileCount"`
+	// Total size of the files, before archiving and compression
+	UncompressedSize int64 `json:"uncompressedSize"`
+	// Digest of the file tree, independent of how the folder is split in layers and compressed
+	ContentDigest digest.Digest `json:"contentDigest"`
+}
+
+// NewArtifactMetadata computes the metadata of the folders being pushed. When several folders are packaged
+// in the same manifest, their content is merged in order, like their layers are on pull. Only the entries
+// selected by the filter are described. The files missing from the digests recorded while packaging the
+// layers are read to compute the content digest.
+func NewArtifactMetadata(filter utils.FolderFilter, fileDigests utils.FileDigests, paths ...string) (*ArtifactMetadata, error) {
+	var sourceFolders []string
+	var entries []utils.TarEntry
+	index := make(map[string]int)
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve folder path: %w", err)
+		}
+		sourceFolders = append(sourceFolders, filepath.Base(absPath))
+
+		// Collected from the path as given, like the layers, so that the entries match the recorded digests
+		folderEntries, err := utils.CollectTarEntries(path, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range folderEntries {
+			if i, ok := index[entry.Name]; ok {
+				entries[i] = entry
+				continue
+			}
+			index[entry.Name] = len(entries)
+			entries = append(entries, entry)
+		}
+	}
+
+	metadata := &ArtifactMetadata{
+		Tool:         ToolName,
+		ToolVersion:  ArtifactCliVersion,
+		SourceFolder: strings.Join(sourceFolders, ", "),
+	}
+	for _, entry := range entries {
+		if entry.Info.Mode().IsRegular() {
+			metadata.FileCount++
+			metadata.UncompressedSize += entry.Info.Size()
+		}
+	}
+
+	var err error
+	metadata.ContentDigest, err = utils.TreeDigest(entries, fileDigests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute content digest: %w", err)
+	}
+	return metadata, nil
+}
+
+// ForPlatform returns a copy of the config for the manifest of the given platform, nil when the
+// manifest is platform independent
+func (c ArtifactConfig) ForPlatform(platform *ocispec.Platform) ArtifactConfig {
+	if platform != nil {
+		c.Architecture = platform.Architecture
+		c.OS = platform.OS
+		c.Variant = platform.Variant
+		c.OSVersion = platform.OSVersion
+	}
+	return c
+}
+
+// Platform returns the platform declared by the config, nil when it does not declare both an OS and an architecture
+func (c ArtifactConfig) Platform() *ocispec.Platform {
+	if c.OS == "" || c.Architecture == "" {
+		return nil
+	}
+	return &ocispec.Platform{
+		OS:           c.OS,
+		Architecture: c.Architecture,
+		Variant:      c.Variant,
+		OSVersion:    c.OSVersion,
+	}
+}
+
+// FetchArtifactConfig fetches and parses the config blob of a manifest. It returns nil when the
+// manifest uses the empty config or a config that is not a JSON document.
+func FetchArtifactConfig(ctx context.Context, fetcher content.Fetcher, configDesc ocispec.Descriptor) (*ArtifactConfig, error) {
+	switch configDesc.MediaType {
+	case OCIConfigMediaType, EducatesConfigMediaType, DockerConfigMediaType:
+	default:
+		return nil, nil
+	}
+
+	configBytes, err := content.FetchAll(ctx, fetcher, configDesc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch config: %w", err)
+	}
+
+	var config ArtifactConfig
+	if err := json.Unmarshal(configBytes, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	return &config, nil
+}
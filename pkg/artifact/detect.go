@@ -0,0 +1,140 @@
+package artifact
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// IdentifyArtifactType inspects the manifest (or the first image manifest of an index), its config media type
+// and its annotations to determine which artifact type was used to lay out the artifact.
+func IdentifyArtifactType(ctx context.Context, fetcher content.Fetcher, rootDesc ocispec.Descriptor) (ArtifactType, error) {
+	rootBytes, err := content.FetchAll(ctx, fetcher, rootDesc)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+
+	manifestDesc := rootDesc
+	manifestBytes := rootBytes
+
+	switch rootDesc.MediaType {
+	case OCIIndexMediaType, DockerIndexMediaType:
+		var index ocispec.Index
+		if err := json.Unmarshal(rootBytes, &index); err != nil {
+			return "", fmt.Errorf("failed to unmarshal index: %w", err)
+		}
+		// Annotations and artifactType on the index take precedence over the ones on the manifests
+		if artifactType, ok := identifyFromAnnotations(index.Annotations); ok {
+			return artifactType, nil
+		}
+		if index.ArtifactType == EducatesArtifactType {
+			return ArtifactTypeEducates, nil
+		}
+		manifestDesc, err = detectionManifest(ctx, fetcher, rootDesc)
+		if err != nil {
+			return "", err
+		}
+		manifestBytes, err = content.FetchAll(ctx, fetcher, manifestDesc)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch manifest %s: %w", manifestDesc.Digest, err)
+		}
+	case OCIManifestMediaType, DockerManifestMediaType:
+	default:
+		return "", fmt.Errorf("unsupported media type: %s", rootDesc.MediaType)
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return "", fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+
+	bundle, err := IsImgpkgBundle(ctx, fetcher, manifest.Config)
+	if err != nil {
+		return "", err
+	}
+
+	return identifyManifest(rootDesc.MediaType, manifestDesc.MediaType, manifest, bundle), nil
+}
+
+// detectionManifest returns the manifest of an index that identifies the artifact type: the first image
+// manifest with a platform, skipping the attestations of docker buildx and the manifests without platform
+// that precede it, or else the first manifest of the index
+func detectionManifest(ctx context.Context, fetcher content.Fetcher, indexDesc ocispec.Descriptor) (ocispec.Descriptor, error) {
+	manifests, err := IndexManifests(ctx, fetcher, indexDesc)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if len(manifests) == 0 {
+		return ocispec.Descriptor{}, fmt.Errorf("index %s does not reference any image manifest", indexDesc.Digest)
+	}
+	for _, manifest := range manifests {
+		if manifest.Platform != nil {
+			return manifest, nil
+		}
+	}
+	return manifests[0], nil
+}
+
+// IsImgpkgBundle reports whether the image config of a manifest carries the imgpkg bundle label
+func IsImgpkgBundle(ctx context.Context, fetcher content.Fetcher, configDesc ocispec.Descriptor) (bool, error) {
+	if configDesc.MediaType != DockerConfigMediaType && configDesc.MediaType != OCIConfigMediaType {
+		return false, nil
+	}
+
+	configBytes, err := content.FetchAll(ctx, fetcher, configDesc)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch config: %w", err)
+	}
+
+	var config ocispec.Image
+	if err := json.Unmarshal(configBytes, &config); err != nil {
+		return false, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	_, ok := config.Config.Labels[ImgpkgBundleLabel]
+	return ok, nil
+}
+
+// identifyManifest determines the artifact type from the content of a single manifest
+func identifyManifest(rootMediaType string, manifestMediaType string, manifest ocispec.Manifest, bundle bool) ArtifactType {
+	if artifactType, ok := identifyFromAnnotations(manifest.Annotations); ok {
+		return artifactType
+	}
+
+	if manifest.ArtifactType == EducatesArtifactType || manifest.Config.MediaType == EducatesConfigMediaType {
+		return ArtifactTypeEducates
+	}
+	for _, layer := range manifest.Layers {
+		if SameLayerMediaType(layer.MediaType, EducatesLayerMediaType) {
+			return ArtifactTypeEducates
+		}
+	}
+
+	if bundle {
+		return ArtifactTypeImgpkg
+	}
+
+	// imgpkg never generates an index and always uses a Docker manifest and config
+	if rootMediaType == DockerManifestMediaType && manifestMediaType == DockerManifestMediaType && manifest.Config.MediaType == DockerConfigMediaType {
+		return ArtifactTypeImgpkg
+	}
+
+	return ArtifactTypeOci
+}
+
+// identifyFromAnnotations returns the artifact type recorded by artifact-cli, if any
+func identifyFromAnnotations(annotations map[string]string) (ArtifactType, bool) {
+	if annotations[AnnotationTool] != ToolName {
+		return "", false
+	}
+	artifactType := ArtifactType(annotations[AnnotationArtifactType])
+	for _, supported := range SupportedArtifactTypes {
+		if artifactType == supported {
+			return artifactType, true
+		}
+	}
+	return "", false
+}
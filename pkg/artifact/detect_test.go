@@ -0,0 +1,154 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+// pushTestJSON pushes a JSON document to the store and returns its descriptor
+func pushTestJSON(t *testing.T, store *memory.Store, mediaType string, v any) ocispec.Descriptor {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := ocispec.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(data), Size: int64(len(data))}
+	if err := store.Push(context.Background(), desc, bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	return desc
+}
+
+// pushTestManifest pushes a manifest with the given config and layer media type, and returns its descriptor
+func pushTestManifest(t *testing.T, store *memory.Store, mediaType string, configMediaType string, config any, layerMediaType string, annotations map[string]string) ocispec.Descriptor {
+	t.Helper()
+	manifest := ocispec.Manifest{
+		Versioned:   specs.Versioned{SchemaVersion: 2},
+		MediaType:   mediaType,
+		Config:      pushTestJSON(t, store, configMediaType, config),
+		Layers:      []ocispec.Descriptor{{MediaType: layerMediaType, Digest: digest.FromString(layerMediaType), Size: 1}},
+		Annotations: annotations,
+	}
+	return pushTestJSON(t, store, mediaType, manifest)
+}
+
+// pushTestIndex pushes an index of the given manifests and returns its descriptor
+func pushTestIndex(t *testing.T, store *memory.Store, manifests ...ocispec.Descriptor) ocispec.Descriptor {
+	t.Helper()
+	index := ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: OCIIndexMediaType,
+		Manifests: manifests,
+	}
+	return pushTestJSON(t, store, OCIIndexMediaType, index)
+}
+
+// withPlatform returns a copy of a manifest descriptor with the given platform
+func withPlatform(desc ocispec.Descriptor, os string, arch string) ocispec.Descriptor {
+	desc.Platform = &ocispec.Platform{OS: os, Architecture: arch}
+	return desc
+}
+
+func TestIdentifyArtifactType(t *testing.T) {
+	linux := map[string]string{"os": "linux", "architecture": "amd64"}
+	bundleConfig := map[string]any{"config": map[string]any{"Labels": map[string]string{ImgpkgBundleLabel: "true"}}}
+
+	tests := []struct {
+		name  string
+		build func(t *testing.T, store *memory.Store) ocispec.Descriptor
+		want  ArtifactType
+	}{
+		{
+			name: "oci manifest",
+			build: func(t *testing.T, store *memory.Store) ocispec.Descriptor {
+				return pushTestManifest(t, store, OCIManifestMediaType, OCIConfigMediaType, linux, OCILayerMediaType, nil)
+			},
+			want: ArtifactTypeOci,
+		},
+		{
+			name: "educates manifest",
+			build: func(t *testing.T, store *memory.Store) ocispec.Descriptor {
+				return pushTestManifest(t, store, OCIManifestMediaType, EducatesConfigMediaType, map[string]string{}, EducatesLayerMediaType, nil)
+			},
+			want: ArtifactTypeEducates,
+		},
+		{
+			name: "artifact-cli annotations",
+			build: func(t *testing.T, store *memory.Store) ocispec.Descriptor {
+				return pushTestManifest(t, store, OCIManifestMediaType, OCIConfigMediaType, linux, OCILayerMediaType, ToolAnnotations(ArtifactTypeEducates))
+			},
+			want: ArtifactTypeEducates,
+		},
+		{
+			name: "imgpkg bundle",
+			build: func(t *testing.T, store *memory.Store) ocispec.Descriptor {
+				return pushTestManifest(t, store, DockerManifestMediaType, DockerConfigMediaType, bundleConfig, DockerLayerMediaType, nil)
+			},
+			want: ArtifactTypeImgpkg,
+		},
+		{
+			name: "docker manifest",
+			build: func(t *testing.T, store *memory.Store) ocispec.Descriptor {
+				return pushTestManifest(t, store, DockerManifestMediaType, DockerConfigMediaType, linux, DockerLayerMediaType, nil)
+			},
+			want: ArtifactTypeImgpkg,
+		},
+		{
+			name: "index of oci manifests",
+			build: func(t *testing.T, store *memory.Store) ocispec.Descriptor {
+				manifest := pushTestManifest(t, store, OCIManifestMediaType, OCIConfigMediaType, linux, OCILayerMediaType, nil)
+				return pushTestIndex(t, store, withPlatform(manifest, "linux", "amd64"))
+			},
+			want: ArtifactTypeOci,
+		},
+		{
+			name: "index starting with an attestation",
+			build: func(t *testing.T, store *memory.Store) ocispec.Descriptor {
+				attestation := pushTestManifest(t, store, OCIManifestMediaType, OCIConfigMediaType, map[string]string{}, "application/vnd.in-toto+json", nil)
+				attestation = withPlatform(attestation, "unknown", "unknown")
+				attestation.Annotations = map[string]string{AnnotationDockerReferenceType: DockerAttestationManifest}
+				manifest := pushTestManifest(t, store, OCIManifestMediaType, EducatesConfigMediaType, linux, EducatesLayerMediaType, nil)
+				return pushTestIndex(t, store, attestation, withPlatform(manifest, "linux", "amd64"))
+			},
+			want: ArtifactTypeEducates,
+		},
+		{
+			name: "index starting with a manifest without platform",
+			build: func(t *testing.T, store *memory.Store) ocispec.Descriptor {
+				other := pushTestManifest(t, store, OCIManifestMediaType, OCIEmptyMediaType, map[string]string{}, "application/octet-stream", nil)
+				manifest := pushTestManifest(t, store, OCIManifestMediaType, EducatesConfigMediaType, linux, EducatesLayerMediaType, nil)
+				return pushTestIndex(t, store, other, withPlatform(manifest, "linux", "amd64"))
+			},
+			want: ArtifactTypeEducates,
+		},
+		{
+			name: "index of manifests without platform",
+			build: func(t *testing.T, store *memory.Store) ocispec.Descriptor {
+				manifest := pushTestManifest(t, store, OCIManifestMediaType, EducatesConfigMediaType, map[string]string{}, EducatesLayerMediaType, nil)
+				return pushTestIndex(t, store, manifest)
+			},
+			want: ArtifactTypeEducates,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.New()
+			rootDesc := tt.build(t, store)
+			got, err := IdentifyArtifactType(context.Background(), store, rootDesc)
+			if err != nil {
+				t.Fatalf("IdentifyArtifactType() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IdentifyArtifactType() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
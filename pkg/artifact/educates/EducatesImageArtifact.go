@@ -0,0 +1,28 @@
+package educates
+
+import (
+	"educates-artifact-cli/pkg/artifact"
+	"educates-artifact-cli/pkg/artifact/oci"
+)
+
+// Spec is the spec for Educates workshop content artifacts. They are laid out like docker buildx
+// images (an OCI index with a manifest per platform) but use their own artifactType and media
+// types, so that Educates tooling can tell them apart from generic OCI images.
+var Spec = oci.Spec{
+	DisplayName:     "Educates",
+	Type:            artifact.ArtifactTypeEducates,
+	ArtifactType:    artifact.EducatesArtifactType,
+	ConfigMediaType: artifact.EducatesConfigMediaType,
+	LayerMediaType:  artifact.EducatesLayerMediaType,
+	Description:     "Educates workshop content created by artifact-cli",
+}
+
+type EducatesImageArtifact struct {
+	*oci.OciImageArtifact
+}
+
+func NewEducatesImageArtifact(repoRef *artifact.RepositoryRef, pushPlatforms []string, pullPlatform string, path string, opts artifact.Options) *EducatesImageArtifact {
+	return &EducatesImageArtifact{
+		OciImageArtifact: oci.NewOciImageArtifactWithSpec(Spec, repoRef, pushPlatforms, pullPlatform, path, opts),
+	}
+}
@@ -0,0 +1,229 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+
+	"educates-artifact-cli/pkg/utils"
+)
+
+// ProcessPulledArtifact processes the pulled artifact and extracts it to the output directory
+func ProcessPulledArtifact(ctx context.Context, store content.Fetcher, pulledDesc ocispec.Descriptor, outputDir string, opts Options) error {
+	utils.VerbosePrintf("Processing pulled artifact with digest: %s\n", pulledDesc.Digest)
+
+	// Fetch the manifest to find our folder layer
+	manifestBytes, err := content.FetchAll(ctx, store, pulledDesc)
+	if err != nil {
+		return fmt.Errorf("failed to fetch manifest from memory store: %w", err)
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+
+	utils.VerbosePrintf("Found manifest with media type %s\n", manifest.MediaType)
+
+	// Check if this is an artifact-cli generated artifact
+	if manifest.Annotations != nil {
+		if tool, exists := manifest.Annotations[AnnotationTool]; exists && tool == ToolName {
+			utils.VerbosePrintf("Detected artifact-cli generated artifact (version: %s)\n", manifest.Annotations[AnnotationVersion])
+		}
+	}
+
+	extractOpts := utils.ExtractOptions{SubPath: opts.SubPath}
+
+	// Find the layers containing our folder tarballs
+	var folderLayerDescs []ocispec.Descriptor
+	switch {
+	case len(opts.LayerMediaTypes) > 0 || len(opts.LayerIndexes) > 0:
+		folderLayerDescs, err = selectLayers(manifest, opts.LayerMediaTypes, opts.LayerIndexes)
+		if err != nil {
+			return err
+		}
+	case opts.ImageLayers:
+		folderLayerDescs = imageLayers(manifest)
+		if len(folderLayerDescs) == 0 {
+			return fmt.Errorf("could not find any filesystem layer in the image (%s)", describeLayers(manifest.Layers))
+		}
+	default:
+		// Files pushed with 'oras push' are named by the title of their layer, otherwise the folder is in tarballs
+		folderLayerDescs = fileLayers(manifest)
+		if len(folderLayerDescs) == 0 {
+			folderLayerDescs = folderLayers(manifest)
+		}
+		if len(folderLayerDescs) == 0 {
+			return fmt.Errorf("could not find folder layer with any supported media type (%s), select the layers to extract by media type or index", describeLayers(manifest.Layers))
+		}
+	}
+	if opts.ImageLayers {
+		// Container images are made of every filesystem layer, applied in order on top of each other
+		extractOpts.Whiteouts = true
+		extractOpts.Extracted = make(utils.ExtractedPaths)
+		extractOpts.SkipUnsupported = true
+	}
+
+	for _, folderLayerDesc := range folderLayerDescs {
+		if !opts.ImageLayers && isFileLayer(folderLayerDesc) {
+			if err := extractFileLayer(ctx, store, folderLayerDesc, outputDir, extractOpts); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := extractLayer(ctx, store, folderLayerDesc, outputDir, extractOpts); err != nil {
+			return err
+		}
+	}
+
+	utils.VerbosePrintln("Successfully pulled and extracted artifact.")
+	return nil
+}
+
+// extractLayer fetches a layer tarball and extracts it to the output directory
+func extractLayer(ctx context.Context, store content.Fetcher, layerDesc ocispec.Descriptor, outputDir string, extractOpts utils.ExtractOptions) error {
+	// Fetch the layer's content (the tarball)
+	tarballBytes, err := content.FetchAll(ctx, store, layerDesc)
+	if err != nil {
+		return fmt.Errorf("failed to fetch layer content: %w", err)
+	}
+
+	// Detect the compression from the media type, falling back to the magic bytes when it is not declared
+	extractOpts.Compression = utils.CompressionFromMediaType(layerDesc.MediaType)
+
+	// Extract the tarball to the output directory
+	if err := utils.ExtractTarball(bytes.NewReader(tarballBytes), outputDir, extractOpts); err != nil {
+		return fmt.Errorf("failed to extract tarball: %w", err)
+	}
+
+	if layerPath, ok := layerDesc.Annotations[AnnotationLayerPath]; ok {
+		utils.VerbosePrintf("Extracted layer for %s: %s\n", layerPath, layerDesc.Digest)
+	}
+	return nil
+}
+
+// extractFileLayer writes the content of a layer holding a single file, at the path of its title annotation
+func extractFileLayer(ctx context.Context, store content.Fetcher, layerDesc ocispec.Descriptor, outputDir string, extractOpts utils.ExtractOptions) error {
+	fileBytes, err := content.FetchAll(ctx, store, layerDesc)
+	if err != nil {
+		return fmt.Errorf("failed to fetch layer content: %w", err)
+	}
+
+	title := layerDesc.Annotations[AnnotationTitle]
+	if err := utils.ExtractFile(bytes.NewReader(fileBytes), outputDir, title, extractOpts); err != nil {
+		return fmt.Errorf("failed to extract file %s: %w", title, err)
+	}
+	utils.VerbosePrintf("Extracted file %s: %s\n", title, layerDesc.Digest)
+	return nil
+}
+
+// isFileLayer reports whether a layer holds a single file named by its title annotation, as pushed by
+// 'oras push'. Directories pushed by 'oras push' are tarballs flagged to be unpacked.
+func isFileLayer(layer ocispec.Descriptor) bool {
+	_, ok := layer.Annotations[AnnotationTitle]
+	return ok && layer.Annotations[AnnotationORASUnpack] != "true"
+}
+
+// fileLayers returns the layers of a manifest named by their title annotation, files and directories
+// pushed by 'oras push' or with --file-layers, in order
+func fileLayers(manifest ocispec.Manifest) []ocispec.Descriptor {
+	var layers []ocispec.Descriptor
+	for _, layer := range manifest.Layers {
+		if title, ok := layer.Annotations[AnnotationTitle]; ok {
+			layers = append(layers, layer)
+			utils.VerbosePrintf("Found layer for %s with media type %s: %s\n", title, layer.MediaType, layer.Digest)
+		}
+	}
+	return layers
+}
+
+// imageLayers returns the filesystem layers of a container image, in order. Any tarball layer is a
+// filesystem layer, whatever the tool that produced it (OCI, Docker rootfs, non distributable layers...)
+func imageLayers(manifest ocispec.Manifest) []ocispec.Descriptor {
+	var layers []ocispec.Descriptor
+	for _, layer := range manifest.Layers {
+		if strings.HasSuffix(TrimCompression(layer.MediaType), ".tar") {
+			layers = append(layers, layer)
+			utils.VerbosePrintf("Found image layer with media type %s: %s\n", layer.MediaType, layer.Digest)
+		} else {
+			utils.VerbosePrintf("Skipping non filesystem layer with media type %s: %s\n", layer.MediaType, layer.Digest)
+		}
+	}
+	return layers
+}
+
+// folderLayerMediaTypes are the media types of the layers holding a folder, by order of preference
+var folderLayerMediaTypes = []string{
+	OCILayerMediaType,            // Our OCI layer type
+	EducatesLayerMediaType,       // Educates layer type
+	FolderArtifactLayerMediaType, // OCI 1.1 artifact manifest layer type
+	DockerLayerMediaType,         // Docker layer type (imgpkg/docker buildx)
+	FolderLayerMediaType,         // Legacy folder layer type
+	HelmChartLayerMediaType,      // Helm chart content
+}
+
+// folderLayers returns the layers holding the folder of an artifact, all the layers of the first
+// supported media type found. Layers are matched regardless of their compression (gzip, zstd or
+// uncompressed), and a folder split into several layers gets all of them, in order.
+func folderLayers(manifest ocispec.Manifest) []ocispec.Descriptor {
+	for _, mediaType := range folderLayerMediaTypes {
+		var layers []ocispec.Descriptor
+		for _, layer := range manifest.Layers {
+			if SameLayerMediaType(layer.MediaType, mediaType) {
+				layers = append(layers, layer)
+				utils.VerbosePrintf("Found layer with media type %s: %s\n", layer.MediaType, layer.Digest)
+			}
+		}
+		if len(layers) > 0 {
+			return layers
+		}
+	}
+	return nil
+}
+
+// selectLayers returns the layers matching one of the media types (regardless of their compression) and
+// one of the indexes, in order. Empty selectors match every layer.
+func selectLayers(manifest ocispec.Manifest, mediaTypes []string, indexes []int) ([]ocispec.Descriptor, error) {
+	for _, index := range indexes {
+		if index < 0 || index >= len(manifest.Layers) {
+			return nil, fmt.Errorf("layer index %d out of range, the manifest has %d layer(s) (%s)", index, len(manifest.Layers), describeLayers(manifest.Layers))
+		}
+	}
+
+	var layers []ocispec.Descriptor
+	for i, layer := range manifest.Layers {
+		if len(indexes) > 0 && !slices.Contains(indexes, i) {
+			continue
+		}
+		if len(mediaTypes) > 0 && !slices.ContainsFunc(mediaTypes, func(mediaType string) bool {
+			return SameLayerMediaType(layer.MediaType, mediaType)
+		}) {
+			continue
+		}
+		layers = append(layers, layer)
+		utils.VerbosePrintf("Selected layer %d with media type %s: %s\n", i, layer.MediaType, layer.Digest)
+	}
+
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("no layer matches the selected media types and indexes (%s)", describeLayers(manifest.Layers))
+	}
+	return layers, nil
+}
+
+// describeLayers lists the index, media type and digest of the layers, for error messages
+func describeLayers(layers []ocispec.Descriptor) string {
+	if len(layers) == 0 {
+		return "the manifest has no layers"
+	}
+	descriptions := make([]string, 0, len(layers))
+	for i, layer := range layers {
+		descriptions = append(descriptions, fmt.Sprintf("[%d] %s %s", i, layer.MediaType, layer.Digest))
+	}
+	return "layers found: " + strings.Join(descriptions, ", ")
+}
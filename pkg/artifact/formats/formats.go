@@ -0,0 +1,49 @@
+package formats
+
+import (
+	"context"
+	"fmt"
+
+	"educates-artifact-cli/pkg/artifact"
+	"educates-artifact-cli/pkg/artifact/educates"
+	"educates-artifact-cli/pkg/artifact/imgpkg"
+	"educates-artifact-cli/pkg/artifact/oci"
+)
+
+// New creates the artifact implementation for the given artifact type
+func New(artifactType artifact.ArtifactType, repoRef *artifact.RepositoryRef, pushPlatforms []string, pullPlatform string, path string, opts artifact.Options) (artifact.Artifact, error) {
+	switch artifactType {
+	case artifact.ArtifactTypeOci:
+		return oci.NewOciImageArtifact(repoRef, pushPlatforms, pullPlatform, path, opts), nil
+	case artifact.ArtifactTypeImgpkg:
+		return imgpkg.NewImgpkgImageArtifact(repoRef, pushPlatforms, pullPlatform, path, opts), nil
+	case artifact.ArtifactTypeEducates:
+		return educates.NewEducatesImageArtifact(repoRef, pushPlatforms, pullPlatform, path, opts), nil
+	default:
+		return nil, fmt.Errorf("unsupported artifact type: %s", artifactType)
+	}
+}
+
+// Detect inspects the artifact in the registry and creates the implementation able to pull it
+func Detect(ctx context.Context, repoRef *artifact.RepositoryRef, pullPlatform string, path string, opts artifact.Options) (artifact.Artifact, artifact.ArtifactType, error) {
+	repo, err := repoRef.Authenticate(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rootDesc, err := repo.Resolve(ctx, repoRef.String())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch descriptor: %w", err)
+	}
+
+	artifactType, err := artifact.IdentifyArtifactType(ctx, repo, rootDesc)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to detect artifact type: %w", err)
+	}
+
+	artifactInstance, err := New(artifactType, repoRef, nil, pullPlatform, path, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	return artifactInstance, artifactType, nil
+}
@@ -7,7 +7,7 @@ import (
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"gopkg.in/yaml.v3"
-	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/registry/remote"
 )
 
@@ -22,11 +22,38 @@ const (
 
 	OCIConfigMediaType = "application/vnd.oci.image.config.v1+json"
 	OCILayerMediaType  = "application/vnd.oci.image.layer.v1.tar+gzip"
+	OCIEmptyMediaType  = "application/vnd.oci.empty.v1+json"
 )
 
 const (
-	DockerLayerMediaType = "application/vnd.docker.image.rootfs.diff.tar.gzip"
-	FolderLayerMediaType = "application/vnd.oci.image.layer.v1.tar+gzip"
+	DockerConfigMediaType = "application/vnd.docker.container.image.v1+json"
+	DockerLayerMediaType  = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+	FolderLayerMediaType  = "application/vnd.oci.image.layer.v1.tar+gzip"
+)
+
+const (
+	// Media types used for OCI 1.1 artifact manifests of a folder, which have an empty config
+	FolderArtifactType           = "application/vnd.educates.artifact-cli.folder.v1"
+	FolderArtifactLayerMediaType = "application/vnd.educates.artifact-cli.folder.v1.tar+gzip"
+)
+
+const (
+	// Media types specific to Educates workshop content artifacts
+	EducatesArtifactType    = "application/vnd.educates.artifact.v1"
+	EducatesConfigMediaType = "application/vnd.educates.artifact.config.v1+json"
+	EducatesLayerMediaType  = "application/vnd.educates.artifact.layer.v1.tar+gzip"
+)
+
+const (
+	// Media type used by 'oras push' for the files pushed without explicit media type. Each file is
+	// a layer holding the raw file content, named by its org.opencontainers.image.title annotation
+	FileLayerMediaType = "application/vnd.oci.image.layer.v1.tar"
+)
+
+const (
+	// Media type of the content layer of the Helm charts pushed with 'helm push', a tarball holding
+	// the chart folder
+	HelmChartLayerMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
 )
 
 const (
@@ -130,7 +157,7 @@ func DetectArtifactType(mediaTypeString string) MediaType {
 	case OCIManifestMediaType:
 		return OCISinglePlatform
 	case DockerManifestMediaType:
-		return OCISinglePlatform
+		return DockerSinglePlatform
 	case DockerIndexMediaType:
 		return DockerMultiPlatform
 	default:
@@ -166,17 +193,28 @@ func (mw *ManifestWrapper) GetMediaType() string {
 }
 
 type ImageMetadata struct {
-	ImageRef      string           `json:"image_ref"`
-	MediaType     MediaType        `json:"media_type"`
-	OciCompliant  bool             `json:"oci_compliant"`
-	MultiPlatform bool             `json:"multi_platform"`
-	Platforms     []PlatformInfo   `json:"platforms,omitempty"`
-	Manifest      *ManifestWrapper `json:"raw_manifest,omitempty"`
+	ImageRef      string       `json:"image_ref"`
+	ArtifactType  ArtifactType `json:"artifact_type"`
+	MediaType     MediaType    `json:"media_type"`
+	OciCompliant  bool         `json:"oci_compliant"`
+	MultiPlatform bool         `json:"multi_platform"`
+	// OCI 1.1 artifactType of the manifests, if any
+	OCIArtifactType string `json:"oci_artifact_type,omitempty"`
+	// True when the manifests are OCI 1.1 artifact manifests using the empty config
+	ArtifactManifest bool `json:"artifact_manifest"`
+	// True when the artifact is an imgpkg bundle, whose config carries the bundle label
+	ImgpkgBundle bool `json:"imgpkg_bundle"`
+	// Config of the manifest, or of the first manifest of an index. Nil for the empty config
+	Config    *ArtifactConfig  `json:"config,omitempty"`
+	Platforms []PlatformInfo   `json:"platforms,omitempty"`
+	Manifest  *ManifestWrapper `json:"raw_manifest,omitempty"`
 }
 
 type PlatformInfo struct {
 	OS           string `json:"os"`
 	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+	OSVersion    string `json:"os_version,omitempty"`
 }
 
 func GetImageMetadata(ctx context.Context, repo *remote.Repository, imageMetadata *ImageMetadata) error {
@@ -187,8 +225,27 @@ func GetImageMetadata(ctx context.Context, repo *remote.Repository, imageMetadat
 		return fmt.Errorf("failed to fetch descriptor: %v", err)
 	}
 
+	// Fetch the manifest by digest, so that it is the one that was resolved
+	fetchedManifestContent, err := content.FetchAll(ctx, repo, descriptor)
+	if err != nil {
+		return fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+
+	// Registries may not return a known media type, it is then taken from the content
+	if DetectArtifactType(descriptor.MediaType) == Undefined {
+		descriptor.MediaType, err = mediaTypeFromContent(fetchedManifestContent)
+		if err != nil {
+			return err
+		}
+	}
 	mediaType := DetectArtifactType(descriptor.MediaType)
 
+	artifactType, err := IdentifyArtifactType(ctx, repo, descriptor)
+	if err != nil {
+		return err
+	}
+	imageMetadata.ArtifactType = artifactType
+
 	imageMetadata.MediaType = mediaType
 	imageMetadata.OciCompliant = mediaType.IsOci()
 	imageMetadata.MultiPlatform = mediaType.IsMultiPlatform()
@@ -197,29 +254,122 @@ func GetImageMetadata(ctx context.Context, repo *remote.Repository, imageMetadat
 		Index:    nil,
 	}
 
-	// Fetch the manifest from the repository by tag
-	_, fetchedManifestContent, err := oras.FetchBytes(ctx, repo, imageMetadata.ImageRef, oras.DefaultFetchBytesOptions)
+	imageMetadata.OCIArtifactType, imageMetadata.ArtifactManifest, err = inspectArtifactManifest(ctx, repo, fetchedManifestContent)
 	if err != nil {
-		return fmt.Errorf("failed to fetch manifest: %w", err)
+		return err
+	}
+
+	imageMetadata.ImgpkgBundle, err = inspectImgpkgBundle(ctx, repo, fetchedManifestContent)
+	if err != nil {
+		return err
+	}
+
+	imageMetadata.Config, err = inspectArtifactConfig(ctx, repo, fetchedManifestContent)
+	if err != nil {
+		return err
 	}
 
-	if imageMetadata.MediaType == OCIMultiPlatform || imageMetadata.MediaType == OCISinglePlatform {
-		// Parse the fetched manifest content and get the layers
+	if mediaType.IsMultiPlatform() {
 		if err := json.Unmarshal(fetchedManifestContent, &imageMetadata.Manifest.Index); err != nil {
-			return fmt.Errorf("failed to unmarshal manifest: %w", err)
+			return fmt.Errorf("failed to unmarshal index: %w", err)
+		}
+		// Copy the platforms of the manifests of the index, including the ones of nested indexes.
+		// Platform independent manifests are not listed.
+		manifests, err := IndexManifests(ctx, repo, descriptor)
+		if err != nil {
+			return err
 		}
-		// Copy the platforms from the manifests in the index to the image metadata
-		for _, manifest := range imageMetadata.Manifest.Index.Manifests {
-			imageMetadata.Platforms = append(imageMetadata.Platforms, PlatformInfo{
-				OS:           manifest.Platform.OS,
-				Architecture: manifest.Platform.Architecture,
-			})
+		for _, manifest := range manifests {
+			if manifest.Platform != nil {
+				imageMetadata.Platforms = append(imageMetadata.Platforms, newPlatformInfo(*manifest.Platform))
+			}
 		}
 	} else {
 		if err := json.Unmarshal(fetchedManifestContent, &imageMetadata.Manifest.Manifest); err != nil {
 			return fmt.Errorf("failed to unmarshal manifest: %w", err)
 		}
+		// A single manifest has no platform field, its platform can only be declared by its config
+		if imageMetadata.Config != nil {
+			if platform := imageMetadata.Config.Platform(); platform != nil {
+				imageMetadata.Platforms = append(imageMetadata.Platforms, newPlatformInfo(*platform))
+			}
+		}
 	}
 
 	return nil
 }
+
+func newPlatformInfo(platform ocispec.Platform) PlatformInfo {
+	return PlatformInfo{
+		OS:           platform.OS,
+		Architecture: platform.Architecture,
+		Variant:      platform.Variant,
+		OSVersion:    platform.OSVersion,
+	}
+}
+
+// inspectArtifactManifest returns the artifactType of a manifest, or of the first manifest of an index,
+// and whether it is an OCI 1.1 artifact manifest (which uses the empty config descriptor)
+func inspectArtifactManifest(ctx context.Context, fetcher content.Fetcher, manifestContent []byte) (string, bool, error) {
+	var probe struct {
+		ArtifactType string               `json:"artifactType"`
+		Config       *ocispec.Descriptor  `json:"config"`
+		Manifests    []ocispec.Descriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(manifestContent, &probe); err != nil {
+		return "", false, fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+
+	if probe.Config != nil {
+		return probe.ArtifactType, probe.Config.MediaType == OCIEmptyMediaType, nil
+	}
+
+	if len(probe.Manifests) == 0 {
+		return probe.ArtifactType, false, nil
+	}
+
+	// All the manifests of an index are generated by the same tool, so the first one is enough
+	childContent, err := content.FetchAll(ctx, fetcher, probe.Manifests[0])
+	if err != nil {
+		return "", false, fmt.Errorf("failed to fetch manifest %s: %w", probe.Manifests[0].Digest, err)
+	}
+	return inspectArtifactManifest(ctx, fetcher, childContent)
+}
+
+// inspectImgpkgBundle returns whether a manifest is an imgpkg bundle. imgpkg never pushes bundles as an index
+func inspectImgpkgBundle(ctx context.Context, fetcher content.Fetcher, manifestContent []byte) (bool, error) {
+	var probe struct {
+		Config *ocispec.Descriptor `json:"config"`
+	}
+	if err := json.Unmarshal(manifestContent, &probe); err != nil {
+		return false, fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+	if probe.Config == nil {
+		return false, nil
+	}
+	return IsImgpkgBundle(ctx, fetcher, *probe.Config)
+}
+
+// inspectArtifactConfig returns the config of a manifest, or of the first manifest of an index
+func inspectArtifactConfig(ctx context.Context, fetcher content.Fetcher, manifestContent []byte) (*ArtifactConfig, error) {
+	var probe struct {
+		Config    *ocispec.Descriptor  `json:"config"`
+		Manifests []ocispec.Descriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(manifestContent, &probe); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+
+	if probe.Config != nil {
+		return FetchArtifactConfig(ctx, fetcher, *probe.Config)
+	}
+	if len(probe.Manifests) == 0 {
+		return nil, nil
+	}
+
+	childContent, err := content.FetchAll(ctx, fetcher, probe.Manifests[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest %s: %w", probe.Manifests[0].Digest, err)
+	}
+	return inspectArtifactConfig(ctx, fetcher, childContent)
+}
@@ -0,0 +1,308 @@
+package imgpkg
+
+import (
+	"bytes"
+	"context"
+	"educates-artifact-cli/pkg/artifact"
+	"educates-artifact-cli/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// ImgpkgImageArtifact pushes and pulls folders using the layout produced by Carvel's imgpkg:
+// a single Docker v2 manifest, a Docker image config and gzipped rootfs layers, with no index.
+type ImgpkgImageArtifact struct {
+	repoRef       *artifact.RepositoryRef
+	pushPlatforms []string
+	pullPlatform  string
+	path          string
+	opts          artifact.Options
+}
+
+func NewImgpkgImageArtifact(repoRef *artifact.RepositoryRef, pushPlatforms []string, pullPlatform string, path string, opts artifact.Options) *ImgpkgImageArtifact {
+	return &ImgpkgImageArtifact{repoRef: repoRef, pushPlatforms: pushPlatforms, pullPlatform: pullPlatform, path: path, opts: opts}
+}
+
+func (a *ImgpkgImageArtifact) Push(ctx context.Context) (*artifact.PushResult, error) {
+	out := a.opts.ProgressWriter()
+	fmt.Fprintf(out, "Imgpkg Artifact Push\n")
+
+	if a.opts.ArtifactManifest {
+		return nil, fmt.Errorf("imgpkg artifacts are always pushed as Docker v2 manifests, OCI artifact manifests are not supported")
+	}
+	if a.opts.Compression != "" && a.opts.Compression != utils.CompressionGzip {
+		return nil, fmt.Errorf("imgpkg artifacts only support gzip compressed layers, %s is not supported", a.opts.Compression)
+	}
+	if a.opts.FileLayers {
+		return nil, fmt.Errorf("imgpkg artifacts are made of rootfs tarballs, file layers are not supported")
+	}
+	if len(a.opts.PlatformFolders) > 0 {
+		return nil, fmt.Errorf("imgpkg artifacts are platform independent, per-platform folders are not supported")
+	}
+
+	// imgpkg does not generate indexes, so there is nothing to do with the platforms
+	if len(a.pushPlatforms) != 0 {
+		utils.VerbosePrintln("when pushing an Imgpkg artifact, platforms will be ignored")
+	}
+
+	// Folders holding a .imgpkg directory are pushed as bundles, like 'imgpkg push -b' does
+	bundle := IsBundleFolder(a.path)
+	var lock *ImagesLock
+	if bundle {
+		var err error
+		lock, err = ReadImagesLock(a.path)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(out, "Folder '%s' holds a %s directory, pushing it as an imgpkg bundle\n", a.path, BundleDir)
+	} else if a.opts.CopyBundleImages {
+		return nil, fmt.Errorf("folder '%s' is not an imgpkg bundle: %s directory not found", a.path, BundleDir)
+	}
+
+	fmt.Fprintf(out, "Packaging folder '%s'...\n", a.path)
+	// Create the layer tarballs of the folder, spooled to temporary files, using the Docker rootfs media type
+	tarOpts, err := a.opts.TarOptions(utils.CompressionGzip)
+	if err != nil {
+		return nil, err
+	}
+	// The files are hashed while packaged, for the content digest of the config
+	tarOpts.FileDigests = make(utils.FileDigests)
+	layers, err := artifact.CreateLayers(a.path, a.opts.LayerPaths, a.opts.FolderFilter(), artifact.DockerLayerMediaType, tarOpts, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tarball: %w", err)
+	}
+	defer artifact.RemoveLayers(layers)
+
+	metadata, err := artifact.NewArtifactMetadata(a.opts.FolderFilter(), tarOpts.FileDigests, a.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute artifact metadata: %w", err)
+	}
+	created, err := a.opts.CreationTime()
+	if err != nil {
+		return nil, err
+	}
+
+	uploader, repo, err := artifact.NewPushUploader(ctx, a.repoRef, a.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Floating tags only move when the pushed version is the latest release of their line
+	var existingTags []string
+	if a.opts.FloatingTags {
+		existingTags, err = uploader.ExistingTags(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	tags, err := a.opts.PushTags(a.repoRef.String(), existingTags)
+	if err != nil {
+		return nil, err
+	}
+
+	if lock != nil && a.opts.CopyBundleImages {
+		if a.opts.DryRun {
+			for _, image := range lock.Images {
+				fmt.Fprintf(out, "Would copy image %s\n", image.Image)
+			}
+		} else if err := CopyImages(ctx, repo, lock, a.repoRef.Insecure, out); err != nil {
+			return nil, err
+		}
+	}
+
+	// Push the folder layers (blobs) to the registry
+	for _, layer := range layers {
+		if err := artifact.PushLayer(ctx, uploader, layer); err != nil {
+			return nil, fmt.Errorf("failed to push layer blob: %w", err)
+		}
+		utils.VerbosePrintf("Pushed layer: %s\n", layer.Descriptor.Digest)
+	}
+
+	// Docker manifests have no annotations, the standard and user annotations are config labels instead
+	labels := a.opts.PushAnnotations(artifact.ToolAnnotations(artifact.ArtifactTypeImgpkg), a.path, created, tags)
+	if bundle {
+		labels[artifact.ImgpkgBundleLabel] = "true"
+	}
+
+	manifestDesc, err := PushDockerManifest(ctx, uploader, layers, labels, artifact.ArtifactConfig{Created: created, Artifact: metadata})
+	if err != nil {
+		return nil, err
+	}
+
+	// Tag the manifest with every requested tag
+	if err := uploader.Tag(ctx, manifestDesc, tags); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(out, "%s\n", uploader.Summary())
+	if !a.opts.DryRun {
+		fmt.Fprintf(out, "\nSuccessfully pushed and tagged artifact: %s\n", a.repoRef.String())
+	}
+	utils.VerbosePrintf("Root digest: %s\n", manifestDesc.Digest)
+
+	return uploader.Result(manifestDesc, tags)
+}
+
+func (a *ImgpkgImageArtifact) Pull(ctx context.Context) error {
+	fmt.Printf("Imgpkg Artifact Pull\n")
+
+	repo, err := a.repoRef.Authenticate(ctx)
+	if err != nil {
+		return err
+	}
+
+	// imgpkg artifacts are always a single manifest, never an index
+	rootDesc, err := repo.Resolve(ctx, a.repoRef.String())
+	if err != nil {
+		return fmt.Errorf("failed to fetch descriptor: %w", err)
+	}
+	if rootDesc.MediaType != artifact.DockerManifestMediaType && rootDesc.MediaType != artifact.OCIManifestMediaType {
+		return fmt.Errorf("artifact %s is not an imgpkg artifact: unexpected media type %s", a.repoRef.String(), rootDesc.MediaType)
+	}
+
+	// Create a memory store to hold the pulled content
+	memStore := memory.New()
+
+	pulledDesc, err := oras.Copy(ctx, repo, a.repoRef.String(), memStore, a.repoRef.String(), oras.DefaultCopyOptions)
+	if err != nil {
+		// Check if the error is a CopyError and return details
+		var copyErr *oras.CopyError
+		if errors.As(err, &copyErr) {
+			return copyErr.Err
+		}
+
+		return err
+	}
+
+	err = artifact.ProcessPulledArtifact(ctx, memStore, pulledDesc, a.path, a.opts)
+	if err != nil {
+		return err
+	}
+
+	if err := a.processBundle(ctx, repo, memStore, pulledDesc); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nSuccessfully pulled and extracted artifact to %s.\n", a.path)
+	return nil
+}
+
+// processBundle reports the images referenced by a pulled imgpkg bundle and relocates them when requested
+func (a *ImgpkgImageArtifact) processBundle(ctx context.Context, repo *remote.Repository, store content.Fetcher, pulledDesc ocispec.Descriptor) error {
+	manifestBytes, err := content.FetchAll(ctx, store, pulledDesc)
+	if err != nil {
+		return fmt.Errorf("failed to fetch manifest from memory store: %w", err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+
+	bundle, err := artifact.IsImgpkgBundle(ctx, store, manifest.Config)
+	if err != nil || !bundle {
+		return err
+	}
+
+	// The images lock is not extracted when pulling a sub-path of the bundle
+	lock, err := ReadImagesLock(a.path)
+	if err != nil {
+		return fmt.Errorf("pulled imgpkg bundle has an invalid images lock: %w", err)
+	}
+	if lock == nil {
+		fmt.Printf("Pulled imgpkg bundle\n")
+		return nil
+	}
+
+	fmt.Printf("Pulled imgpkg bundle referencing %d image(s)\n", len(lock.Images))
+	for _, image := range lock.Images {
+		utils.VerbosePrintf("  - %s\n", image.Image)
+	}
+
+	if !a.opts.RelocateBundleImages {
+		return nil
+	}
+	relocated, err := RelocateImages(ctx, repo, lock)
+	if err != nil {
+		return err
+	}
+	if !relocated {
+		fmt.Printf("One or more images not found in bundle repository, skipping images lock update\n")
+		return nil
+	}
+	if err := WriteImagesLock(a.path, lock); err != nil {
+		return err
+	}
+	fmt.Printf("Updated images lock to reference the images of the bundle repository\n")
+	return nil
+}
+
+// PushDockerManifest pushes a Docker image config and a Docker v2 manifest referencing the given layers.
+// The config records the uncompressed digests (diff_ids) of the layers, the given labels and the creation
+// time and metadata of the artifact config.
+func PushDockerManifest(ctx context.Context, pusher content.Pusher, layers []artifact.Layer, labels map[string]string, artifactConfig artifact.ArtifactConfig) (ocispec.Descriptor, error) {
+	// The Docker image config shares its JSON layout with the OCI image config.
+	// The artifact metadata is an extra field, ignored by imgpkg and docker
+	config := struct {
+		ocispec.Image
+		Artifact *artifact.ArtifactMetadata `json:"artifact,omitempty"`
+	}{
+		Image: ocispec.Image{
+			Created: artifactConfig.Created,
+			Config: ocispec.ImageConfig{
+				Labels: labels,
+			},
+			RootFS: *artifact.NewRootFS(layers),
+		},
+		Artifact: artifactConfig.Artifact,
+	}
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	configDesc := ocispec.Descriptor{
+		MediaType: artifact.DockerConfigMediaType,
+		Digest:    digest.FromBytes(configBytes),
+		Size:      int64(len(configBytes)),
+	}
+	if err := pusher.Push(ctx, configDesc, bytes.NewReader(configBytes)); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to push config blob: %w", err)
+	}
+	utils.VerbosePrintf("Pushed config: %s\n", configDesc.Digest)
+
+	// Docker v2 manifests share their JSON layout with OCI manifests, only the media types differ
+	manifest := ocispec.Manifest{
+		Versioned: specs.Versioned{
+			SchemaVersion: 2,
+		},
+		MediaType: artifact.DockerManifestMediaType,
+		Config:    configDesc,
+		Layers:    artifact.LayerDescriptors(layers),
+	}
+
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	manifestDesc := ocispec.Descriptor{
+		MediaType: artifact.DockerManifestMediaType,
+		Digest:    digest.FromBytes(manifestBytes),
+		Size:      int64(len(manifestBytes)),
+	}
+	if err := pusher.Push(ctx, manifestDesc, bytes.NewReader(manifestBytes)); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to push manifest: %w", err)
+	}
+	utils.VerbosePrintf("Pushed manifest: %s\n", manifestDesc.Digest)
+
+	return manifestDesc, nil
+}
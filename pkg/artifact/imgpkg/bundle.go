@@ -0,0 +1,165 @@
+package imgpkg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"gopkg.in/yaml.v3"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+
+	"educates-artifact-cli/pkg/artifact"
+	"educates-artifact-cli/pkg/utils"
+)
+
+const (
+	// BundleDir is the directory holding the bundle metadata at the root of an imgpkg bundle folder
+	BundleDir = ".imgpkg"
+	// ImagesLockFile is the file of the bundle directory listing the images referenced by the bundle
+	ImagesLockFile = "images.yml"
+
+	ImagesLockAPIVersion = "imgpkg.carvel.dev/v1alpha1"
+	ImagesLockKind       = "ImagesLock"
+)
+
+// ImagesLock is the content of the .imgpkg/images.yml file of a bundle
+type ImagesLock struct {
+	APIVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Images     []ImageLock `yaml:"images"`
+}
+
+// ImageLock is an image referenced by a bundle, always by digest
+type ImageLock struct {
+	Image       string            `yaml:"image"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Digest returns the digest part of the image reference
+func (i ImageLock) Digest() (digest.Digest, error) {
+	_, dgst, found := strings.Cut(i.Image, "@")
+	if !found {
+		return "", fmt.Errorf("image %s of the images lock is not referenced by digest", i.Image)
+	}
+	parsed, err := digest.Parse(dgst)
+	if err != nil {
+		return "", fmt.Errorf("image %s of the images lock has an invalid digest: %w", i.Image, err)
+	}
+	return parsed, nil
+}
+
+// IsBundleFolder reports whether a folder holds a .imgpkg directory, and must be pushed as a bundle
+func IsBundleFolder(path string) bool {
+	info, err := os.Stat(filepath.Join(path, BundleDir))
+	return err == nil && info.IsDir()
+}
+
+// ReadImagesLock reads and validates the images lock of a bundle folder.
+// It returns nil when the bundle does not reference any image.
+func ReadImagesLock(path string) (*ImagesLock, error) {
+	lockBytes, err := os.ReadFile(filepath.Join(path, BundleDir, ImagesLockFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read images lock: %w", err)
+	}
+
+	var lock ImagesLock
+	if err := yaml.Unmarshal(lockBytes, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse images lock: %w", err)
+	}
+	if lock.APIVersion != ImagesLockAPIVersion || lock.Kind != ImagesLockKind {
+		return nil, fmt.Errorf("unsupported images lock %s/%s (expected %s/%s)", lock.APIVersion, lock.Kind, ImagesLockAPIVersion, ImagesLockKind)
+	}
+	for _, image := range lock.Images {
+		if _, err := image.Digest(); err != nil {
+			return nil, err
+		}
+	}
+	return &lock, nil
+}
+
+// WriteImagesLock writes the images lock of a bundle folder
+func WriteImagesLock(path string, lock *ImagesLock) error {
+	// imgpkg indents the images lock with two spaces
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(lock); err != nil {
+		return fmt.Errorf("failed to marshal images lock: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(path, BundleDir, ImagesLockFile), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write images lock: %w", err)
+	}
+	return nil
+}
+
+// CopyImages copies the images referenced by a bundle into the bundle repository, so that the bundle
+// can be relocated with them. Copies are tagged the way imgpkg does (sha256-<hex>.imgpkg), and reported to out.
+// insecure only applies to the images of the registry of the bundle: the other registries are reached over
+// HTTPS, unless they are local (localhost or 127.0.0.1).
+func CopyImages(ctx context.Context, repo *remote.Repository, lock *ImagesLock, insecure bool, out io.Writer) error {
+	for _, image := range lock.Images {
+		dgst, err := image.Digest()
+		if err != nil {
+			return err
+		}
+		imageRef, err := registry.ParseReference(image.Image)
+		if err != nil {
+			return fmt.Errorf("invalid image reference %s: %w", image.Image, err)
+		}
+
+		// Referenced images are usually public, so they are fetched anonymously
+		srcRef := &artifact.RepositoryRef{URL: image.Image, Insecure: insecure && imageRef.Registry == repo.Reference.Registry}
+		srcRepo, err := srcRef.Authenticate(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create repository client for %s: %w", image.Image, err)
+		}
+
+		tag := fmt.Sprintf("%s-%s.imgpkg", dgst.Algorithm(), dgst.Encoded())
+		fmt.Fprintf(out, "Copying image %s...\n", image.Image)
+		if _, err := oras.Copy(ctx, srcRepo, dgst.String(), repo, tag, oras.DefaultCopyOptions); err != nil {
+			var copyErr *oras.CopyError
+			if errors.As(err, &copyErr) {
+				err = copyErr.Err
+			}
+			return fmt.Errorf("failed to copy image %s: %w", image.Image, err)
+		}
+		utils.VerbosePrintf("Copied image %s as %s\n", image.Image, tag)
+	}
+	return nil
+}
+
+// RelocateImages rewrites the images lock to reference the copies of the images stored in the bundle
+// repository. Like imgpkg, the lock is left untouched unless every image was copied with the bundle.
+func RelocateImages(ctx context.Context, repo *remote.Repository, lock *ImagesLock) (bool, error) {
+	bundleRepo := repo.Reference.Registry + "/" + repo.Reference.Repository
+
+	relocated := make([]ImageLock, 0, len(lock.Images))
+	for _, image := range lock.Images {
+		dgst, err := image.Digest()
+		if err != nil {
+			return false, err
+		}
+		if _, err := repo.Resolve(ctx, dgst.String()); err != nil {
+			utils.VerbosePrintf("Image %s not found in bundle repository: %v\n", image.Image, err)
+			return false, nil
+		}
+		relocated = append(relocated, ImageLock{
+			Image:       bundleRepo + "@" + dgst.String(),
+			Annotations: image.Annotations,
+		})
+	}
+
+	lock.Images = relocated
+	return true, nil
+}
@@ -0,0 +1,209 @@
+package imgpkg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"oras.land/oras-go/v2/registry/remote"
+
+	"educates-artifact-cli/pkg/artifact"
+)
+
+var (
+	testImageDigest1 = digest.FromString("image1")
+	testImageDigest2 = digest.FromString("image2")
+)
+
+// writeTestLock writes the images lock of a bundle folder
+func writeTestLock(t *testing.T, content string) string {
+	t.Helper()
+	path := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(path, BundleDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, BundleDir, ImagesLockFile), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadImagesLock(t *testing.T) {
+	header := "apiVersion: " + ImagesLockAPIVersion + "\nkind: " + ImagesLockKind + "\n"
+
+	tests := []struct {
+		name    string
+		content string
+		want    []ImageLock
+		wantErr bool
+	}{
+		{
+			name:    "valid lock",
+			content: header + "images:\n- image: ghcr.io/org/app@" + testImageDigest1.String() + "\n  annotations:\n    kbld.carvel.dev/id: app\n",
+			want:    []ImageLock{{Image: "ghcr.io/org/app@" + testImageDigest1.String(), Annotations: map[string]string{"kbld.carvel.dev/id": "app"}}},
+		},
+		{
+			name:    "lock without images",
+			content: header + "images: []\n",
+			want:    []ImageLock{},
+		},
+		{
+			name:    "image referenced by tag",
+			content: header + "images:\n- image: ghcr.io/org/app:1.0.0\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid digest",
+			content: header + "images:\n- image: ghcr.io/org/app@sha256:abc\n",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported kind",
+			content: "apiVersion: " + ImagesLockAPIVersion + "\nkind: BundleLock\nimages: []\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid YAML",
+			content: header + "images: {\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lock, err := ReadImagesLock(writeTestLock(t, tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadImagesLock() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(lock.Images, tt.want) {
+				t.Errorf("ReadImagesLock() images = %v, want %v", lock.Images, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadImagesLockMissing(t *testing.T) {
+	lock, err := ReadImagesLock(t.TempDir())
+	if err != nil || lock != nil {
+		t.Errorf("ReadImagesLock() = %v, %v, want no lock", lock, err)
+	}
+}
+
+func TestWriteImagesLock(t *testing.T) {
+	path := writeTestLock(t, "")
+	lock := &ImagesLock{
+		APIVersion: ImagesLockAPIVersion,
+		Kind:       ImagesLockKind,
+		Images:     []ImageLock{{Image: "ghcr.io/org/app@" + testImageDigest1.String(), Annotations: map[string]string{"a": "b"}}},
+	}
+	if err := WriteImagesLock(path, lock); err != nil {
+		t.Fatalf("WriteImagesLock() error = %v", err)
+	}
+	read, err := ReadImagesLock(path)
+	if err != nil {
+		t.Fatalf("ReadImagesLock() error = %v", err)
+	}
+	if !reflect.DeepEqual(read, lock) {
+		t.Errorf("ReadImagesLock() = %v, want %v", read, lock)
+	}
+}
+
+// newTestRepository serves the manifests of the given digests, and returns a client of its 'bundle' repository
+func newTestRepository(t *testing.T, digests ...digest.Digest) *remote.Repository {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, dgst := range digests {
+			if r.URL.Path == "/v2/bundle/manifests/"+dgst.String() {
+				w.Header().Set("Content-Type", artifact.OCIManifestMediaType)
+				w.Header().Set("Docker-Content-Digest", dgst.String())
+				w.Header().Set("Content-Length", strconv.Itoa(len(dgst.String())))
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	repo, err := remote.NewRepository(strings.TrimPrefix(server.URL, "http://") + "/bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.PlainHTTP = true
+	return repo
+}
+
+func TestRelocateImages(t *testing.T) {
+	image1 := "ghcr.io/org/app@" + testImageDigest1.String()
+	image2 := "docker.io/library/db@" + testImageDigest2.String()
+
+	tests := []struct {
+		name          string
+		inRepository  []digest.Digest
+		images        []string
+		wantRelocated bool
+		// Repository of the relocated images, relative to the registry of the bundle
+		wantImages []string
+	}{
+		{
+			name:          "every image copied",
+			inRepository:  []digest.Digest{testImageDigest1, testImageDigest2},
+			images:        []string{image1, image2},
+			wantRelocated: true,
+			wantImages:    []string{"bundle@" + testImageDigest1.String(), "bundle@" + testImageDigest2.String()},
+		},
+		{
+			name:          "partially copied",
+			inRepository:  []digest.Digest{testImageDigest1},
+			images:        []string{image1, image2},
+			wantRelocated: false,
+			wantImages:    []string{image1, image2},
+		},
+		{
+			name:          "no image copied",
+			images:        []string{image1},
+			wantRelocated: false,
+			wantImages:    []string{image1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepository(t, tt.inRepository...)
+			lock := &ImagesLock{APIVersion: ImagesLockAPIVersion, Kind: ImagesLockKind}
+			for _, image := range tt.images {
+				lock.Images = append(lock.Images, ImageLock{Image: image, Annotations: map[string]string{"id": image}})
+			}
+
+			relocated, err := RelocateImages(context.Background(), repo, lock)
+			if err != nil {
+				t.Fatalf("RelocateImages() error = %v", err)
+			}
+			if relocated != tt.wantRelocated {
+				t.Errorf("RelocateImages() = %v, want %v", relocated, tt.wantRelocated)
+			}
+
+			for i, image := range lock.Images {
+				want := tt.wantImages[i]
+				if tt.wantRelocated {
+					want = repo.Reference.Registry + "/" + want
+				}
+				if image.Image != want {
+					t.Errorf("image %d = %s, want %s", i, image.Image, want)
+				}
+				if image.Annotations["id"] != tt.images[i] {
+					t.Errorf("image %d annotations = %v, want the original ones", i, image.Annotations)
+				}
+			}
+		})
+	}
+}
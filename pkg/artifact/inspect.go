@@ -0,0 +1,101 @@
+package artifact
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// IsIndexMediaType reports whether a media type is an OCI image index or a Docker manifest list
+func IsIndexMediaType(mediaType string) bool {
+	return mediaType == OCIIndexMediaType || mediaType == DockerIndexMediaType
+}
+
+// IsManifestMediaType reports whether a media type is an OCI image manifest or a Docker v2 manifest
+func IsManifestMediaType(mediaType string) bool {
+	return mediaType == OCIManifestMediaType || mediaType == DockerManifestMediaType
+}
+
+// mediaTypeFromContent returns the media type of a manifest or index whose descriptor does not declare
+// a known one, using the mediaType field of the content or, when missing, its layout
+func mediaTypeFromContent(manifestContent []byte) (string, error) {
+	var probe struct {
+		MediaType string          `json:"mediaType"`
+		Config    json.RawMessage `json:"config"`
+		Manifests json.RawMessage `json:"manifests"`
+	}
+	if err := json.Unmarshal(manifestContent, &probe); err != nil {
+		return "", fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+	switch {
+	case IsIndexMediaType(probe.MediaType) || IsManifestMediaType(probe.MediaType):
+		return probe.MediaType, nil
+	case probe.Manifests != nil:
+		return OCIIndexMediaType, nil
+	case probe.Config != nil:
+		return OCIManifestMediaType, nil
+	default:
+		return "", fmt.Errorf("content is neither a manifest nor an index")
+	}
+}
+
+// IndexManifests returns the manifests referenced by an index. Nested indexes are flattened, the attestation
+// manifests added by docker buildx are skipped, and the platform of the manifests that don't declare one is
+// inferred from their config. Manifests whose platform is still unknown are returned without platform.
+func IndexManifests(ctx context.Context, fetcher content.Fetcher, indexDesc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	indexBytes, err := content.FetchAll(ctx, fetcher, indexDesc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch index %s: %w", indexDesc.Digest, err)
+	}
+
+	var index ocispec.Index
+	if err := json.Unmarshal(indexBytes, &index); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal index %s: %w", indexDesc.Digest, err)
+	}
+
+	var manifests []ocispec.Descriptor
+	for _, manifest := range index.Manifests {
+		if IsIndexMediaType(manifest.MediaType) {
+			nested, err := IndexManifests(ctx, fetcher, manifest)
+			if err != nil {
+				return nil, err
+			}
+			manifests = append(manifests, nested...)
+			continue
+		}
+		if manifest.Annotations[AnnotationDockerReferenceType] == DockerAttestationManifest {
+			continue
+		}
+		if manifest.Platform == nil {
+			manifest.Platform, err = InferPlatform(ctx, fetcher, manifest)
+			if err != nil {
+				return nil, err
+			}
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
+
+// InferPlatform returns the platform recorded in the config of a manifest, nil when the config
+// does not declare one (e.g. artifacts pushed without platform or using the empty config)
+func InferPlatform(ctx context.Context, fetcher content.Fetcher, manifestDesc ocispec.Descriptor) (*ocispec.Platform, error) {
+	manifestBytes, err := content.FetchAll(ctx, fetcher, manifestDesc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest %s: %w", manifestDesc.Digest, err)
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal manifest %s: %w", manifestDesc.Digest, err)
+	}
+
+	config, err := FetchArtifactConfig(ctx, fetcher, manifest.Config)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	return config.Platform(), nil
+}
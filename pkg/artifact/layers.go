@@ -0,0 +1,268 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+
+	"educates-artifact-cli/pkg/utils"
+)
+
+// Layer is a folder layer ready to be pushed. Its content is spooled to a temporary file, or read from the
+// packaged file itself, so that the memory used by a push does not depend on the size of the folder.
+type Layer struct {
+	Descriptor ocispec.Descriptor
+	// DiffID is the digest of the uncompressed content of the layer, recorded in the rootfs of the config
+	DiffID digest.Digest
+	// Path of the file holding the content of the layer
+	Path string
+	// temporary is true when the file was created for the layer, and must be removed once pushed
+	temporary bool
+}
+
+// Open opens the content of the layer
+func (l Layer) Open() (io.ReadCloser, error) {
+	return os.Open(l.Path)
+}
+
+// RemoveLayers removes the temporary files holding the content of the layers
+func RemoveLayers(layers []Layer) {
+	for _, layer := range layers {
+		if !layer.temporary {
+			continue
+		}
+		if err := os.Remove(layer.Path); err != nil {
+			utils.VerbosePrintf("Failed to remove temporary layer file %s: %v\n", layer.Path, err)
+		}
+	}
+}
+
+// PushLayer streams the content of a layer to the registry
+func PushLayer(ctx context.Context, pusher content.Pusher, layer Layer) error {
+	file, err := layer.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open layer content: %w", err)
+	}
+	defer file.Close()
+	return pusher.Push(ctx, layer.Descriptor, file)
+}
+
+// CreateLayers packages a folder into layers of the given media type. Every layer path becomes its own
+// layer, in the given order, and the remaining content of the folder goes into a final layer. Without
+// layer paths the whole folder is a single layer.
+// Layer annotations are only added when annotate is true, as Docker manifests do not support them.
+// Only the entries selected by the filter are packaged. The layers are spooled to temporary files, to be
+// removed with RemoveLayers once pushed.
+func CreateLayers(path string, layerPaths []string, filter utils.FolderFilter, mediaType string, tarOpts utils.TarOptions, annotate bool) ([]Layer, error) {
+	subPaths := make([]string, 0, len(layerPaths))
+	for _, layerPath := range layerPaths {
+		subPath, err := utils.NormalizeSubPath(layerPath)
+		if err != nil {
+			return nil, err
+		}
+		subPaths = append(subPaths, subPath)
+	}
+
+	entries, err := utils.CollectTarEntries(path, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := utils.PartitionTarEntries(entries, subPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	var layers []Layer
+	for i, group := range groups {
+		if group == nil {
+			continue
+		}
+
+		layerPath := "."
+		if i < len(subPaths) {
+			layerPath = subPaths[i]
+		}
+
+		layer, err := spoolTarball(group, tarOpts)
+		if err != nil {
+			RemoveLayers(layers)
+			return nil, fmt.Errorf("failed to create tarball for %s: %w", layerPath, err)
+		}
+
+		layer.Descriptor.MediaType = WithCompression(mediaType, tarOpts.Compression)
+		if annotate {
+			layer.Descriptor.Annotations = map[string]string{
+				AnnotationLayerPath: layerPath,
+			}
+		}
+		utils.VerbosePrintf("Packaged layer for %s (%d entries): %s\n", layerPath, len(group), layer.Descriptor.Digest)
+
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+// spoolTarball writes the entries as a tarball to a temporary file, computing its digest, size and diff id
+// on the way, so that the tarball is never held in memory
+func spoolTarball(entries []utils.TarEntry, tarOpts utils.TarOptions) (Layer, error) {
+	file, err := os.CreateTemp("", "artifact-cli-layer-*")
+	if err != nil {
+		return Layer{}, fmt.Errorf("failed to create temporary layer file: %w", err)
+	}
+	layer := Layer{Path: file.Name(), temporary: true}
+
+	digester := digest.Canonical.Digester()
+	counter := &countingWriter{w: io.MultiWriter(file, digester.Hash())}
+	diffID, err := utils.WriteTarball(counter, entries, tarOpts)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		RemoveLayers([]Layer{layer})
+		return Layer{}, err
+	}
+
+	layer.Descriptor = ocispec.Descriptor{
+		Digest: digester.Digest(),
+		Size:   counter.n,
+	}
+	layer.DiffID = diffID
+	return layer, nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// CreateFileLayers packages every regular file of a folder as its own layer of the given media type, in the
+// layout used by 'oras push': the layer holds the raw file content and is named by its title annotation.
+// Directories are not packaged, so empty directories are lost. The layers are read from the files themselves.
+// The digests of the files are recorded in fileDigests, when set.
+func CreateFileLayers(path string, filter utils.FolderFilter, mediaType string, fileDigests utils.FileDigests) ([]Layer, error) {
+	entries, err := utils.CollectTarEntries(path, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var layers []Layer
+	for _, entry := range entries {
+		if entry.Link != "" {
+			utils.VerbosePrintf("Skipping symlink %s, file layers only hold regular files\n", entry.Name)
+			continue
+		}
+		if !entry.Info.Mode().IsRegular() {
+			continue
+		}
+
+		fileDigest, size, err := digestFile(entry.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", entry.Path, err)
+		}
+		if fileDigests != nil {
+			fileDigests[entry.Path] = fileDigest
+		}
+
+		desc := ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    fileDigest,
+			Size:      size,
+			Annotations: map[string]string{
+				AnnotationTitle: entry.Name,
+			},
+		}
+		utils.VerbosePrintf("Packaged file layer for %s: %s\n", entry.Name, desc.Digest)
+
+		// The layers are not compressed, so their diff id is their digest
+		layers = append(layers, Layer{Descriptor: desc, DiffID: fileDigest, Path: entry.Path})
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("folder '%s' does not hold any file", path)
+	}
+	return layers, nil
+}
+
+// digestFile returns the digest and size of a file, reading it as a stream
+func digestFile(path string) (digest.Digest, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	digester := digest.Canonical.Digester()
+	size, err := io.Copy(digester.Hash(), file)
+	if err != nil {
+		return "", 0, err
+	}
+	return digester.Digest(), size, nil
+}
+
+// NewRootFS returns the rootfs of an image config holding the given layers, in order
+func NewRootFS(layers []Layer) *ocispec.RootFS {
+	rootFS := &ocispec.RootFS{Type: "layers", DiffIDs: make([]digest.Digest, 0, len(layers))}
+	for _, layer := range layers {
+		rootFS.DiffIDs = append(rootFS.DiffIDs, layer.DiffID)
+	}
+	return rootFS
+}
+
+// LayerDescriptors returns the descriptors of the layers, in order
+func LayerDescriptors(layers []Layer) []ocispec.Descriptor {
+	descriptors := make([]ocispec.Descriptor, 0, len(layers))
+	for _, layer := range layers {
+		descriptors = append(descriptors, layer.Descriptor)
+	}
+	return descriptors
+}
+
+// compressionSuffixes are the suffixes used by OCI (+) and Docker (.) layer media types to declare the compression
+var compressionSuffixes = []string{"+gzip", "+zstd", ".gzip", ".zstd"}
+
+// TrimCompression returns the layer media type without its compression suffix
+func TrimCompression(mediaType string) string {
+	for _, suffix := range compressionSuffixes {
+		if strings.HasSuffix(mediaType, suffix) {
+			return strings.TrimSuffix(mediaType, suffix)
+		}
+	}
+	return mediaType
+}
+
+// WithCompression returns the layer media type declaring the given compression.
+// Gzip is used when no compression is provided.
+func WithCompression(mediaType string, compression utils.Compression) string {
+	base := TrimCompression(mediaType)
+	// Docker media types use '.' to separate the compression (e.g. 'tar.gzip'), OCI ones use '+'
+	separator := "+"
+	if strings.HasSuffix(mediaType, ".gzip") || strings.HasSuffix(mediaType, ".zstd") {
+		separator = "."
+	}
+	switch compression {
+	case utils.CompressionZstd:
+		return base + separator + "zstd"
+	case utils.CompressionNone:
+		return base
+	default:
+		return base + separator + "gzip"
+	}
+}
+
+// SameLayerMediaType returns true if both layer media types are equal regardless of their compression
+func SameLayerMediaType(a, b string) bool {
+	return TrimCompression(a) == TrimCompression(b)
+}
@@ -8,7 +8,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"slices"
+	"sort"
+	"time"
 
 	"github.com/opencontainers/go-digest"
 	"github.com/opencontainers/image-spec/specs-go"
@@ -16,7 +17,6 @@ import (
 	"oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/content/memory"
-	"oras.land/oras-go/v2/registry/remote"
 )
 
 type OciImageArtifact struct {
@@ -24,83 +24,210 @@ type OciImageArtifact struct {
 	pushPlatforms []string
 	pullPlatform  string
 	path          string
+	spec          Spec
+	opts          artifact.Options
 }
 
-func NewOciImageArtifact(repoRef *artifact.RepositoryRef, pushPlatforms []string, pullPlatform string, path string) *OciImageArtifact {
-	return &OciImageArtifact{repoRef: repoRef, pushPlatforms: pushPlatforms, pullPlatform: pullPlatform, path: path}
+func NewOciImageArtifact(repoRef *artifact.RepositoryRef, pushPlatforms []string, pullPlatform string, path string, opts artifact.Options) *OciImageArtifact {
+	return NewOciImageArtifactWithSpec(OciSpec, repoRef, pushPlatforms, pullPlatform, path, opts)
 }
 
-func (a *OciImageArtifact) Push(ctx context.Context) error {
-	fmt.Printf("OCI Artifact Push\n")
-	fmt.Printf("Packaging folder '%s'...\n", a.path)
-	// Create a tarball of the folder in memory
-	tarballBytes, err := utils.CreateTarGz(a.path)
+// NewOciImageArtifactWithSpec creates an artifact that uses the media types and annotations of the given spec
+func NewOciImageArtifactWithSpec(spec Spec, repoRef *artifact.RepositoryRef, pushPlatforms []string, pullPlatform string, path string, opts artifact.Options) *OciImageArtifact {
+	if opts.ArtifactManifest {
+		spec = spec.AsArtifactManifest()
+	}
+	return &OciImageArtifact{repoRef: repoRef, pushPlatforms: pushPlatforms, pullPlatform: pullPlatform, path: path, spec: spec, opts: opts}
+}
+
+func (a *OciImageArtifact) Push(ctx context.Context) (*artifact.PushResult, error) {
+	out := a.opts.ProgressWriter()
+	fmt.Fprintf(out, "%s Artifact Push\n", a.spec.DisplayName)
+
+	tarOpts, err := a.opts.TarOptions(a.opts.Compression)
 	if err != nil {
-		return fmt.Errorf("failed to create tarball: %w", err)
+		return nil, err
+	}
+	// The files are hashed while packaged, for the content digest of the config
+	tarOpts.FileDigests = make(utils.FileDigests)
+	created, err := a.opts.CreationTime()
+	if err != nil {
+		return nil, err
 	}
 
-	// Create a new registry client with authentication
-	// repo, err := artifact.CreateAuthenticatedRepository(ctx, a.repoRef)
-	repo, err := a.repoRef.Authenticate(ctx)
+	// Create a new registry client with authentication, unless this is a dry run
+	uploader, _, err := artifact.NewPushUploader(ctx, a.repoRef, a.opts)
 	if err != nil {
-		return fmt.Errorf("failed to create repository client: %w", err)
+		return nil, err
 	}
 
-	// Push the folder layer (blob) to the registry. This is shared across all platforms.
-	// Use OCI layer media type for compatibility
-	layerDesc := ocispec.Descriptor{
-		MediaType: artifact.OCILayerMediaType,
-		Digest:    digest.FromBytes(tarballBytes),
-		Size:      int64(len(tarballBytes)),
+	// Floating tags only move when the pushed version is the latest release of their line
+	var existingTags []string
+	if a.opts.FloatingTags {
+		existingTags, err = uploader.ExistingTags(ctx)
+		if err != nil {
+			return nil, err
+		}
 	}
-	if err := repo.Push(ctx, layerDesc, bytes.NewReader(tarballBytes)); err != nil {
-		return fmt.Errorf("failed to push layer blob: %w", err)
+	tags, err := a.opts.PushTags(a.repoRef.String(), existingTags)
+	if err != nil {
+		return nil, err
 	}
-	utils.VerbosePrintf("Pushed layer: %s\n", layerDesc.Digest)
-
-	var rootDesc ocispec.Descriptor
 
-	// When no platforms are provided, we use the default platforms (linux/amd64 and linux/arm64) as well
-	// as the current platform
-	if len(a.pushPlatforms) == 0 {
-		a.pushPlatforms = utils.SlicePlatforms(utils.DefaultPlatforms)
-		currentPlatform := utils.GetOSPlatformStr()
-		// If current platform is not in the default platforms, add it
-		if !slices.Contains(a.pushPlatforms, currentPlatform) {
-			a.pushPlatforms = append(a.pushPlatforms, currentPlatform)
+	// Push the folder layers (blobs) to the registry. These are shared across all platforms.
+	// With per-platform folders, the folder is optional and holds the common content.
+	var layers []artifact.Layer
+	if a.path != "" {
+		fmt.Fprintf(out, "Packaging folder '%s'...\n", a.path)
+		layers, err = a.pushFolderLayers(ctx, uploader, a.path, a.opts.LayerPaths, tarOpts)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	// Create annotations
-	annotations := map[string]string{
-		"org.opencontainers.image.title":       "artifact-cli artifact",
-		"org.opencontainers.image.description": "Created by artifact-cli",
+	var rootDesc ocispec.Descriptor
+
+	// Create annotations, read from the git repository of the pushed folder when available
+	annotations := a.opts.PushAnnotations(a.spec.Annotations(), a.gitFolder(), created, tags)
+
+	if len(a.opts.PlatformFolders) > 0 {
+		// --- Per-Platform Folders (Index) Push ---
+		utils.VerbosePrintf("Performing a multi-platform push with per-platform folders for: %s\n", a.pushPlatforms)
+		rootDesc, err = a.pushPlatformFolders(ctx, uploader, layers, tarOpts, created, annotations)
+	} else {
+		// Describe the folder in the config blob, so that it can be inspected without downloading the layers
+		var metadata *artifact.ArtifactMetadata
+		metadata, err = artifact.NewArtifactMetadata(a.opts.FolderFilter(), tarOpts.FileDigests, a.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute artifact metadata: %w", err)
+		}
+		config := artifact.ArtifactConfig{Created: created, RootFS: artifact.NewRootFS(layers), Artifact: metadata}
+		layerDescs := artifact.LayerDescriptors(layers)
+
+		if len(a.pushPlatforms) == 0 {
+			// --- Single Manifest Push ---
+			// Folder content is platform independent, so when no platforms are provided a single
+			// manifest without platform selector is pushed and no index is created
+			utils.VerbosePrintln("Performing a single manifest push without platform selector")
+			rootDesc, err = PushSingleManifest(ctx, uploader, a.spec, layerDescs, config, nil, annotations)
+		} else {
+			// --- Multi-Platform (Index) Push ---
+			utils.VerbosePrintf("Performing a multi-platform push for: %s\n", a.pushPlatforms)
+			rootDesc, err = PushImageIndex(ctx, uploader, a.spec, layerDescs, config, a.pushPlatforms, annotations)
+		}
 	}
-	// --- Multi-Platform (Index) Push ---
-	utils.VerbosePrintf("Performing a multi-platform push for: %s\n", a.pushPlatforms)
-	rootDesc, err = PushImageIndex(ctx, repo, layerDesc, a.pushPlatforms, annotations)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Tag the root manifest/index with the provided tag
-	tag := utils.GetTagFromRef(a.repoRef.String())
-	if err := repo.Tag(ctx, rootDesc, tag); err != nil {
-		return fmt.Errorf("failed to tag root descriptor: %w", err)
+	// Tag the root manifest/index with every requested tag
+	if err := uploader.Tag(ctx, rootDesc, tags); err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("\nSuccessfully pushed and tagged artifact: %s\n", a.repoRef.String())
+	fmt.Fprintf(out, "%s\n", uploader.Summary())
+	if !a.opts.DryRun {
+		fmt.Fprintf(out, "\nSuccessfully pushed and tagged artifact: %s\n", a.repoRef.String())
+	}
 	utils.VerbosePrintf("Root digest: %s\n", rootDesc.Digest)
 
-	return nil
+	return uploader.Result(rootDesc, tags)
+}
 
+// gitFolder returns the folder whose git repository describes the pushed artifact: the common folder, or
+// else the folder of the first platform
+func (a *OciImageArtifact) gitFolder() string {
+	if a.path != "" || len(a.opts.PlatformFolders) == 0 {
+		return a.path
+	}
+	var platforms []string
+	for platformStr := range a.opts.PlatformFolders {
+		platforms = append(platforms, platformStr)
+	}
+	sort.Strings(platforms)
+	return a.opts.PlatformFolders[platforms[0]]
+}
+
+// pushPlatformFolders pushes a manifest per platform, made of the common layers followed by the layers
+// of the platform folder, and the index referencing them
+func (a *OciImageArtifact) pushPlatformFolders(ctx context.Context, pusher content.Pusher, commonLayers []artifact.Layer, tarOpts utils.TarOptions, created *time.Time, annotations map[string]string) (ocispec.Descriptor, error) {
+	platforms := a.pushPlatforms
+	if len(platforms) == 0 {
+		for platformStr := range a.opts.PlatformFolders {
+			platforms = append(platforms, platformStr)
+		}
+		sort.Strings(platforms)
+	}
+
+	var manifestDescriptors []ocispec.Descriptor
+	for _, platformStr := range platforms {
+		folder, ok := a.opts.PlatformFolders[platformStr]
+		if !ok {
+			return ocispec.Descriptor{}, fmt.Errorf("no folder provided for platform %s", platformStr)
+		}
+
+		var platform ocispec.Platform
+		if err := utils.ParsePlatform(&platform, platformStr); err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to parse platform: %w", err)
+		}
+
+		fmt.Fprintf(a.opts.ProgressWriter(), "Packaging folder '%s' for platform %s...\n", folder, platformStr)
+		platformLayers, err := a.pushFolderLayers(ctx, pusher, folder, nil, tarOpts)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		layers := append(append([]artifact.Layer{}, commonLayers...), platformLayers...)
+
+		metadata, err := artifact.NewArtifactMetadata(a.opts.FolderFilter(), tarOpts.FileDigests, a.path, folder)
+		if err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to compute artifact metadata: %w", err)
+		}
+		config := artifact.ArtifactConfig{Created: created, RootFS: artifact.NewRootFS(layers), Artifact: metadata}
+
+		manifestDesc, err := PushSingleManifest(ctx, pusher, a.spec, artifact.LayerDescriptors(layers), config, &platform, annotations)
+		if err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to push manifest for platform %s/%s: %w", platform.OS, platform.Architecture, err)
+		}
+		manifestDescriptors = append(manifestDescriptors, manifestDesc)
+	}
+
+	return PushIndex(ctx, pusher, a.spec, manifestDescriptors, annotations)
+}
+
+// pushFolderLayers packages a folder into layers and pushes them. The returned layers describe the pushed
+// content, their temporary files are already removed.
+func (a *OciImageArtifact) pushFolderLayers(ctx context.Context, pusher content.Pusher, path string, layerPaths []string, tarOpts utils.TarOptions) ([]artifact.Layer, error) {
+	var layers []artifact.Layer
+	var err error
+	if a.opts.FileLayers {
+		// One layer per file, in the layout of 'oras push'
+		layers, err = artifact.CreateFileLayers(path, a.opts.FolderFilter(), artifact.FileLayerMediaType, tarOpts.FileDigests)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create file layers: %w", err)
+		}
+	} else {
+		// Create the layer tarballs of the folder, spooled to temporary files
+		layers, err = artifact.CreateLayers(path, layerPaths, a.opts.FolderFilter(), a.spec.LayerMediaType, tarOpts, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tarball: %w", err)
+		}
+	}
+
+	defer artifact.RemoveLayers(layers)
+
+	for _, layer := range layers {
+		if err := artifact.PushLayer(ctx, pusher, layer); err != nil {
+			return nil, fmt.Errorf("failed to push layer blob: %w", err)
+		}
+		utils.VerbosePrintf("Pushed layer: %s\n", layer.Descriptor.Digest)
+	}
+	return layers, nil
 }
 
 func (a *OciImageArtifact) Pull(ctx context.Context) error {
-	fmt.Printf("OCI Artifact Pull\n")
+	fmt.Printf("%s Artifact Pull\n", a.spec.DisplayName)
 
 	// Create a new registry client with authentication
-	// repo, err := artifact.CreateAuthenticatedRepository(ctx, a.repoRef)
 	repo, err := a.repoRef.Authenticate(ctx)
 	if err != nil {
 		return err
@@ -117,25 +244,34 @@ func (a *OciImageArtifact) Pull(ctx context.Context) error {
 	// Create a memory store to hold the pulled content
 	memStore := memory.New()
 
-	// Define copy options to specify the target platform
-	// If image is multi-platform, we need to pull the image for the target platform
-	// If image is single-platform, we need to pull the image for the current platform
-	copyOpts := oras.DefaultCopyOptions
-	if imageMetadata.MediaType == artifact.OCIMultiPlatform {
-		var targetPlatform ocispec.Platform
-		err = utils.ParsePlatform(&targetPlatform, a.pullPlatform)
+	// If image is multi-platform, we need to pull the manifest that best matches the target platform
+	// If image is a single manifest, it is pulled as is, whatever the target platform
+	srcRef := a.repoRef.String()
+	if imageMetadata.MultiPlatform {
+		targetPlatform, err := utils.NewPlatform(a.pullPlatform)
 		if err != nil {
 			return fmt.Errorf("failed to parse platform: %w", err)
 		}
-		copyOpts.WithTargetPlatform(&targetPlatform)
-		fmt.Printf("Pulling artifact for platform %s/%s...\n", targetPlatform.OS, targetPlatform.Architecture)
+		rootDesc, err := repo.Resolve(ctx, a.repoRef.String())
+		if err != nil {
+			return fmt.Errorf("failed to fetch descriptor: %w", err)
+		}
+		manifestDesc, err := artifact.SelectPlatformManifest(ctx, repo, rootDesc, targetPlatform)
+		if err != nil {
+			return err
+		}
+		srcRef = manifestDesc.Digest.String()
+		if manifestDesc.Platform != nil {
+			fmt.Printf("Pulling artifact for platform %s...\n", utils.PlatformFromOCI(*manifestDesc.Platform))
+		} else {
+			fmt.Printf("Pulling platform independent manifest of the index...\n")
+		}
 	} else {
-		currentPlatform := utils.GetOSPlatformStr()
-		fmt.Printf("Pulling artifact for current platform: %s\n", currentPlatform)
+		fmt.Printf("Pulling single manifest artifact (platform independent)...\n")
 	}
 
 	// Use oras.Copy to pull the artifact
-	pulledDesc, err := oras.Copy(ctx, repo, a.repoRef.String(), memStore, a.repoRef.String(), copyOpts)
+	pulledDesc, err := oras.Copy(ctx, repo, srcRef, memStore, srcRef, oras.DefaultCopyOptions)
 	if err != nil {
 		// Check if the error is a CopyError and return details
 		var copyErr *oras.CopyError
@@ -146,7 +282,7 @@ func (a *OciImageArtifact) Pull(ctx context.Context) error {
 		return err
 	}
 
-	err = processPulledArtifact(ctx, memStore, pulledDesc, a.path)
+	err = artifact.ProcessPulledArtifact(ctx, memStore, pulledDesc, a.path, a.opts)
 	if err != nil {
 		return err
 	}
@@ -155,106 +291,7 @@ func (a *OciImageArtifact) Pull(ctx context.Context) error {
 	return nil
 }
 
-// processPulledArtifact processes the pulled artifact and extracts it to the output directory
-func processPulledArtifact(ctx context.Context, memStore *memory.Store, pulledDesc ocispec.Descriptor, outputDir string) error {
-	utils.VerbosePrintf("Processing pulled artifact with digest: %s\n", pulledDesc.Digest)
-
-	// Fetch the manifest to find our folder layer
-	manifestBytes, err := content.FetchAll(ctx, memStore, pulledDesc)
-	if err != nil {
-		return fmt.Errorf("failed to fetch manifest from memory store: %w", err)
-	}
-
-	var manifest ocispec.Manifest
-	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
-		return fmt.Errorf("failed to unmarshal manifest: %w", err)
-	}
-
-	utils.VerbosePrintf("Found manifest with media type %s\n", manifest.MediaType)
-
-	// Check if this is an artifact-cli generated artifact
-	if manifest.Annotations != nil {
-		if tool, exists := manifest.Annotations["dev.educates.artifact-cli.tool"]; exists && tool == "artifact-cli" {
-			utils.VerbosePrintf("Detected artifact-cli generated artifact (version: %s)\n", manifest.Annotations["dev.educates.artifact-cli.version"])
-		}
-	}
-
-	// Find the specific layer containing our folder tarball
-	// Try different media types for compatibility
-	var folderLayerDesc *ocispec.Descriptor
-	layerMediaTypes := []string{
-		artifact.OCILayerMediaType,    // Our OCI layer type
-		artifact.DockerLayerMediaType, // Docker layer type (imgpkg/docker buildx)
-		artifact.FolderLayerMediaType, // Legacy folder layer type
-	}
-
-	for _, mediaType := range layerMediaTypes {
-		for _, layer := range manifest.Layers {
-			if layer.MediaType == mediaType {
-				folderLayerDesc = &layer
-				utils.VerbosePrintf("Found layer with media type %s: %s\n", mediaType, layer.Digest)
-				break
-			}
-		}
-		if folderLayerDesc != nil {
-			break
-		}
-	}
-
-	if folderLayerDesc == nil {
-		return fmt.Errorf("could not find folder layer with any supported media type")
-	}
-
-	// Fetch the layer's content (the tarball)
-	tarballBytes, err := content.FetchAll(ctx, memStore, *folderLayerDesc)
-	if err != nil {
-		return fmt.Errorf("failed to fetch layer content: %w", err)
-	}
-
-	// Extract the tarball to the output directory
-	if err := utils.ExtractTarGz(bytes.NewReader(tarballBytes), outputDir); err != nil {
-		return fmt.Errorf("failed to extract tarball: %w", err)
-	}
-
-	utils.VerbosePrintln("Successfully pulled and extracted artifact.")
-	return nil
-}
-
-// // isOciCliArtifact checks if the pulled descriptor is an artifact-cli generated artifact
-// func isOciCliArtifact(ctx context.Context, memStore *memory.Store, desc ocispec.Descriptor) bool {
-// 	// Fetch the manifest to check annotations
-// 	manifestBytes, err := content.FetchAll(ctx, memStore, desc)
-// 	if err != nil {
-// 		return false
-// 	}
-
-// 	var manifest ocispec.Manifest
-// 	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
-// 		return false
-// 	}
-
-// 	// Check for artifact-cli specific annotations
-// 	if manifest.Annotations != nil {
-// 		if tool, exists := manifest.Annotations["dev.educates.artifact-cli.tool"]; exists && tool == "artifact-cli" {
-// 			return true
-// 		}
-// 	}
-
-// 	return false
-// }
-
-// func pushImageIndex(ctx context.Context, repo *remote.Repository, layerDesc ocispec.Descriptor, platforms []string) (ocispec.Descriptor, error) {
-// 	annotations := map[string]string{
-// 		"org.opencontainers.image.title":          "artifact-cli artifact",
-// 		"org.opencontainers.image.description":    "Folder artifact created by artifact-cli",
-// 		"dev.educates.artifact-cli.version":       artifact.ArtifactCliVersion,
-// 		"dev.educates.artifact-cli.tool":          "artifact-cli",
-// 		"dev.educates.artifact-cli.artifact-type": "oci",
-// 	}
-// 	return artifact.PushImageIndex(ctx, repo, layerDesc, platforms, annotations)
-// }
-
-func PushImageIndex(ctx context.Context, repo *remote.Repository, layerDesc ocispec.Descriptor, platforms []string, annotations map[string]string) (ocispec.Descriptor, error) {
+func PushImageIndex(ctx context.Context, pusher content.Pusher, spec Spec, layerDescs []ocispec.Descriptor, config artifact.ArtifactConfig, platforms []string, annotations map[string]string) (ocispec.Descriptor, error) {
 	var manifestDescriptors []ocispec.Descriptor
 
 	utils.VerbosePrintf("Pushing index...\n")
@@ -268,21 +305,27 @@ func PushImageIndex(ctx context.Context, repo *remote.Repository, layerDesc ocis
 
 		utils.VerbosePrintf("Processing platform %s/%s...\n", platform.OS, platform.Architecture)
 
-		manifestDesc, err := PushSingleManifest(ctx, repo, layerDesc, &platform, annotations)
+		manifestDesc, err := PushSingleManifest(ctx, pusher, spec, layerDescs, config, &platform, annotations)
 		if err != nil {
 			return ocispec.Descriptor{}, fmt.Errorf("failed to push manifest for platform %s/%s: %w", platform.OS, platform.Architecture, err)
 		}
 		manifestDescriptors = append(manifestDescriptors, manifestDesc)
 	}
 
+	return PushIndex(ctx, pusher, spec, manifestDescriptors, annotations)
+}
+
+// PushIndex pushes an index referencing the given platform manifests
+func PushIndex(ctx context.Context, pusher content.Pusher, spec Spec, manifestDescriptors []ocispec.Descriptor, annotations map[string]string) (ocispec.Descriptor, error) {
 	// Create the image index
 	index := ocispec.Index{
 		Versioned: specs.Versioned{
 			SchemaVersion: 2,
 		},
-		MediaType:   artifact.OCIIndexMediaType,
-		Manifests:   manifestDescriptors,
-		Annotations: annotations,
+		MediaType:    artifact.OCIIndexMediaType,
+		ArtifactType: spec.ArtifactType,
+		Manifests:    manifestDescriptors,
+		Annotations:  annotations,
 	}
 
 	indexBytes, err := json.Marshal(index)
@@ -296,7 +339,7 @@ func PushImageIndex(ctx context.Context, repo *remote.Repository, layerDesc ocis
 		Size:      int64(len(indexBytes)),
 	}
 
-	if err := repo.Push(ctx, indexDesc, bytes.NewReader(indexBytes)); err != nil {
+	if err := pusher.Push(ctx, indexDesc, bytes.NewReader(indexBytes)); err != nil {
 		return ocispec.Descriptor{}, fmt.Errorf("failed to push index: %w", err)
 	}
 	utils.VerbosePrintf("Pushed index: %s\n", indexDesc.Digest)
@@ -304,15 +347,28 @@ func PushImageIndex(ctx context.Context, repo *remote.Repository, layerDesc ocis
 	return indexDesc, nil
 }
 
-func PushSingleManifest(ctx context.Context, repo *remote.Repository, layerDesc ocispec.Descriptor, platform *ocispec.Platform, annotations map[string]string) (ocispec.Descriptor, error) {
-	// Create and push a minimal config blob
-	configBytes := []byte("{}")
-	configDesc := ocispec.Descriptor{
-		MediaType: artifact.OCIConfigMediaType,
-		Digest:    digest.FromBytes(configBytes),
-		Size:      int64(len(configBytes)),
+// PushSingleManifest pushes the config and the manifest referencing the given layers. The config holds
+// the artifact metadata and the platform of the manifest, if any.
+func PushSingleManifest(ctx context.Context, pusher content.Pusher, spec Spec, layerDescs []ocispec.Descriptor, config artifact.ArtifactConfig, platform *ocispec.Platform, annotations map[string]string) (ocispec.Descriptor, error) {
+	var configDesc ocispec.Descriptor
+	var configBytes []byte
+	if spec.ConfigMediaType == artifact.OCIEmptyMediaType {
+		// OCI 1.1 artifact manifests use the well-known empty descriptor as config
+		configDesc = ocispec.DescriptorEmptyJSON
+		configBytes = ocispec.DescriptorEmptyJSON.Data
+	} else {
+		var err error
+		configBytes, err = json.Marshal(config.ForPlatform(platform))
+		if err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to marshal config: %w", err)
+		}
+		configDesc = ocispec.Descriptor{
+			MediaType: spec.ConfigMediaType,
+			Digest:    digest.FromBytes(configBytes),
+			Size:      int64(len(configBytes)),
+		}
 	}
-	if err := repo.Push(ctx, configDesc, bytes.NewReader(configBytes)); err != nil {
+	if err := pusher.Push(ctx, configDesc, bytes.NewReader(configBytes)); err != nil {
 		return ocispec.Descriptor{}, fmt.Errorf("failed to push config blob: %w", err)
 	}
 	utils.VerbosePrintf("Pushed config: %s\n", configDesc.Digest)
@@ -322,13 +378,15 @@ func PushSingleManifest(ctx context.Context, repo *remote.Repository, layerDesc
 		Versioned: specs.Versioned{
 			SchemaVersion: 2,
 		},
-		Config:      configDesc,
-		Layers:      []ocispec.Descriptor{layerDesc},
-		MediaType:   artifact.OCIManifestMediaType,
-		Annotations: annotations,
+		Config:       configDesc,
+		Layers:       layerDescs,
+		MediaType:    artifact.OCIManifestMediaType,
+		ArtifactType: spec.ArtifactType,
+		// Every manifest gets its own copy, so that the platform annotation does not leak to the others
+		Annotations: artifact.MergeAnnotations(annotations),
 	}
 	if platform != nil {
-		manifest.Annotations["org.opencontainers.image.platform"] = fmt.Sprintf("%s/%s", platform.OS, platform.Architecture)
+		manifest.Annotations[artifact.AnnotationPlatform] = utils.PlatformFromOCI(*platform).String()
 	}
 
 	manifestBytes, err := json.Marshal(manifest)
@@ -337,13 +395,14 @@ func PushSingleManifest(ctx context.Context, repo *remote.Repository, layerDesc
 	}
 
 	manifestDesc := ocispec.Descriptor{
-		MediaType: artifact.OCIManifestMediaType,
-		Digest:    digest.FromBytes(manifestBytes),
-		Size:      int64(len(manifestBytes)),
-		Platform:  platform,
+		MediaType:    artifact.OCIManifestMediaType,
+		ArtifactType: spec.ArtifactType,
+		Digest:       digest.FromBytes(manifestBytes),
+		Size:         int64(len(manifestBytes)),
+		Platform:     platform,
 	}
 
-	if err := repo.Push(ctx, manifestDesc, bytes.NewReader(manifestBytes)); err != nil {
+	if err := pusher.Push(ctx, manifestDesc, bytes.NewReader(manifestBytes)); err != nil {
 		return ocispec.Descriptor{}, fmt.Errorf("failed to push manifest: %w", err)
 	}
 
@@ -0,0 +1,45 @@
+package oci
+
+import "educates-artifact-cli/pkg/artifact"
+
+// Spec describes the media types and identification used when building the manifests and index
+// of an artifact, so that formats built on top of OCI manifests can share the push and pull logic.
+type Spec struct {
+	// Name used in user facing messages
+	DisplayName string
+	// Artifact type recorded in the dev.educates.artifact-cli.artifact-type annotation
+	Type artifact.ArtifactType
+	// Value of the artifactType field of the manifests and index. Omitted when empty
+	ArtifactType    string
+	ConfigMediaType string
+	LayerMediaType  string
+	Description     string
+}
+
+// OciSpec is the spec for plain OCI image artifacts, compatible with docker buildx images
+var OciSpec = Spec{
+	DisplayName:     "OCI",
+	Type:            artifact.ArtifactTypeOci,
+	ConfigMediaType: artifact.OCIConfigMediaType,
+	LayerMediaType:  artifact.OCILayerMediaType,
+	Description:     "Created by artifact-cli",
+}
+
+// Annotations returns the annotations added to the manifests and index pushed with this spec
+func (s Spec) Annotations() map[string]string {
+	annotations := artifact.ToolAnnotations(s.Type)
+	annotations[artifact.AnnotationTitle] = "artifact-cli artifact"
+	annotations[artifact.AnnotationDescription] = s.Description
+	return annotations
+}
+
+// AsArtifactManifest returns a copy of the spec that pushes OCI 1.1 artifact manifests. The config is
+// the empty descriptor, so the artifact is identified by its artifactType and its layer media type.
+func (s Spec) AsArtifactManifest() Spec {
+	if s.ArtifactType == "" {
+		s.ArtifactType = artifact.FolderArtifactType
+		s.LayerMediaType = artifact.FolderArtifactLayerMediaType
+	}
+	s.ConfigMediaType = artifact.OCIEmptyMediaType
+	return s
+}
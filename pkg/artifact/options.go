@@ -0,0 +1,128 @@
+package artifact
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"educates-artifact-cli/pkg/utils"
+)
+
+// Options holds the optional settings that tune how an artifact is pushed and pulled.
+// The zero value keeps the default behaviour of every artifact type.
+type Options struct {
+	// ArtifactManifest pushes OCI 1.1 artifact manifests, identified by their artifactType and
+	// using the empty config descriptor, instead of image manifests
+	ArtifactManifest bool
+	// Compression used for the pushed layers. Defaults to gzip when empty
+	Compression utils.Compression
+	// Reproducible pushes the same layers and configs for the same folder content, whoever pushes
+	// it and whenever
+	Reproducible bool
+	// Tags are applied to the pushed artifact in addition to the tag of its reference
+	Tags []string
+	// FloatingTags also tags the pushed artifact with the major.minor and major versions of its
+	// semantic version tags, e.g. '1.4' and '1' for '1.4.2'
+	FloatingTags bool
+	// MountFrom is a repository of the same registry holding some of the pushed blobs, which are
+	// mounted from it instead of being uploaded
+	MountFrom string
+	// Annotations are added to the pushed manifests and index, overriding the default and standard ones
+	Annotations map[string]string
+	// StandardAnnotations adds the created, source, revision and version standard annotations, read
+	// from the git repository holding the pushed folder when available
+	StandardAnnotations bool
+	// Progress receives the progress messages of a push, the standard output when nil
+	Progress io.Writer
+	// DryRun packages the artifact and prints what would be pushed, without contacting the registry
+	DryRun bool
+	// LayerPaths are sub-paths of the folder pushed as their own layer, so that unchanged
+	// content is deduplicated by the registry. The rest of the folder goes into a final layer
+	LayerPaths []string
+	// IgnoreFile is read instead of the .artifactignore file at the root of the pushed folders
+	IgnoreFile string
+	// IncludePaths and ExcludePaths are doublestar patterns selecting the pushed files, relative to the
+	// pushed folders, like the include and exclude paths of the sync config
+	IncludePaths []string
+	ExcludePaths []string
+	// Symlinks is how the symlinks of the pushed folders are packaged. Symlinks pointing outside of
+	// the folders are always refused
+	Symlinks utils.SymlinkPolicy
+	// FileLayers pushes every file of the folder as its own uncompressed layer, named by its
+	// org.opencontainers.image.title annotation, like 'oras push' does. Compression and LayerPaths
+	// do not apply
+	FileLayers bool
+	// PlatformFolders maps every pushed platform (e.g. 'linux/amd64') to the folder holding its content.
+	// Each platform manifest gets the layers of its folder, after the layers of the common folder if any
+	PlatformFolders map[string]string
+	// ImageLayers pulls the artifact as a container image: every filesystem layer is applied in
+	// order, whatever its media type, honouring the OCI/AUFS whiteouts
+	ImageLayers bool
+	// LayerMediaTypes selects the layers to extract by media type, regardless of their compression,
+	// instead of the folder layers of the supported media types (e.g. the content of a Helm chart)
+	LayerMediaTypes []string
+	// LayerIndexes selects the layers to extract by their 0-based position in the manifest.
+	// Combined with LayerMediaTypes, the layers must match both
+	LayerIndexes []int
+	// SubPath restricts the pulled content to this path of the artifact, which is extracted
+	// at the root of the output folder
+	SubPath string
+	// CopyBundleImages copies the images referenced by the .imgpkg/images.yml lock of an imgpkg
+	// bundle into the bundle repository when pushing it
+	CopyBundleImages bool
+	// RelocateBundleImages rewrites the images lock of a pulled imgpkg bundle to reference the
+	// copies of the images stored in the bundle repository, when all of them were copied
+	RelocateBundleImages bool
+}
+
+// TarOptions returns the options used to write the layer tarballs with the given compression
+func (o Options) TarOptions(compression utils.Compression) (utils.TarOptions, error) {
+	if !o.Reproducible {
+		return utils.TarOptions{Compression: compression}, nil
+	}
+	return utils.NewReproducibleTarOptions(compression)
+}
+
+// FolderFilter returns the filter selecting the entries of the pushed folders
+func (o Options) FolderFilter() utils.FolderFilter {
+	return utils.FolderFilter{
+		IgnoreFile: o.IgnoreFile,
+		Include:    o.IncludePaths,
+		Exclude:    o.ExcludePaths,
+		Symlinks:   o.Symlinks,
+	}
+}
+
+// ProgressWriter returns the writer receiving the progress messages of a push
+func (o Options) ProgressWriter() io.Writer {
+	if o.Progress == nil {
+		return os.Stdout
+	}
+	return o.Progress
+}
+
+// PushTags returns the tags applied to an artifact pushed to the given reference, given the existing tags
+// of the repository, see PushTags
+func (o Options) PushTags(ref string, existing []string) ([]string, error) {
+	return PushTags(ref, o.Tags, o.FloatingTags, existing)
+}
+
+// CreationTime returns the creation time recorded in the config of the pushed artifacts. Reproducible
+// artifacts use the SOURCE_DATE_EPOCH environment variable, and have no creation time when it is not set.
+func (o Options) CreationTime() (*time.Time, error) {
+	if o.Reproducible {
+		return utils.SourceDateEpoch()
+	}
+	created := time.Now().UTC()
+	return &created, nil
+}
+
+// PushAnnotations returns the annotations of the manifests and index of an artifact pushed from the folder
+// with the given tags: the default annotations, then the standard annotations, then the user annotations.
+func (o Options) PushAnnotations(defaults map[string]string, folder string, created *time.Time, tags []string) map[string]string {
+	var standard map[string]string
+	if o.StandardAnnotations {
+		standard = StandardAnnotations(folder, created, tags)
+	}
+	return MergeAnnotations(defaults, standard, o.Annotations)
+}
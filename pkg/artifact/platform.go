@@ -0,0 +1,51 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+
+	"educates-artifact-cli/pkg/utils"
+)
+
+// SelectPlatformManifest returns the manifest of an index that best matches the target platform: the exact
+// platform if available, otherwise the closest compatible one (see utils.MatchPlatform). Manifests without
+// platform are platform independent and only selected when no platform matches. Nested indexes are searched
+// and the platform of the manifests that don't declare one is inferred from their config (see IndexManifests).
+func SelectPlatformManifest(ctx context.Context, fetcher content.Fetcher, indexDesc ocispec.Descriptor, target utils.Platform) (ocispec.Descriptor, error) {
+	manifests, err := IndexManifests(ctx, fetcher, indexDesc)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+
+	var candidates []ocispec.Descriptor
+	var platforms []utils.Platform
+	var platformIndependent []ocispec.Descriptor
+	for _, manifest := range manifests {
+		if manifest.Platform == nil {
+			platformIndependent = append(platformIndependent, manifest)
+			continue
+		}
+		candidates = append(candidates, manifest)
+		platforms = append(platforms, utils.PlatformFromOCI(*manifest.Platform))
+	}
+
+	if best := utils.MatchPlatform(target, platforms); best != -1 {
+		if platforms[best].String() != target.String() {
+			utils.VerbosePrintf("No manifest for platform %s, using the compatible platform %s\n", target, platforms[best])
+		}
+		return candidates[best], nil
+	}
+	if len(platformIndependent) > 0 {
+		return platformIndependent[0], nil
+	}
+
+	available := make([]string, 0, len(platforms))
+	for _, platform := range platforms {
+		available = append(available, platform.String())
+	}
+	return ocispec.Descriptor{}, fmt.Errorf("no manifest for platform %s (available: %s)", target, strings.Join(available, ", "))
+}
@@ -0,0 +1,84 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/memory"
+
+	"educates-artifact-cli/pkg/utils"
+)
+
+func TestSelectPlatformManifest(t *testing.T) {
+	tests := []struct {
+		name string
+		// Platforms of the manifests of the index, empty for a platform independent manifest
+		platforms []string
+		// inConfig records the platforms in the configs of the manifests instead of the index
+		inConfig bool
+		target   string
+		// Index of the selected manifest, -1 when none can be selected
+		want int
+	}{
+		{name: "exact platform", platforms: []string{"linux/amd64", "linux/arm64"}, target: "linux/arm64", want: 1},
+		{name: "arm v6 with v6 and v7", platforms: []string{"linux/arm/v7", "linux/arm/v6"}, target: "linux/arm/v6", want: 1},
+		{name: "arm v7 with v6 and v7", platforms: []string{"linux/arm/v6", "linux/arm/v7"}, target: "linux/arm/v7", want: 1},
+		{name: "arm v7 falls back to v6", platforms: []string{"linux/amd64", "linux/arm/v6"}, target: "linux/arm/v7", want: 1},
+		{name: "arm v6 cannot use v7", platforms: []string{"linux/amd64", "linux/arm/v7"}, target: "linux/arm/v6", want: -1},
+		{name: "missing platform", platforms: []string{"linux/amd64", "linux/arm64"}, target: "linux/s390x", want: -1},
+		{name: "missing platform falls back to the platform independent manifest", platforms: []string{"linux/amd64", "", "linux/arm64"}, target: "linux/s390x", want: 1},
+		{name: "platform preferred to the platform independent manifest", platforms: []string{"", "linux/amd64"}, target: "linux/amd64", want: 1},
+		{name: "platform inferred from the config", platforms: []string{"linux/amd64", "linux/arm64"}, inConfig: true, target: "linux/arm64", want: 1},
+		{name: "windows OS version", platforms: []string{"windows(10.0.17763)/amd64", "windows(10.0.20348)/amd64"}, target: "windows(10.0.20348)/amd64", want: 1},
+		{name: "windows OS version inferred from the config", platforms: []string{"windows(10.0.17763)/amd64", "windows(10.0.20348)/amd64"}, inConfig: true, target: "windows(10.0.20348)/amd64", want: 1},
+		{name: "windows OS version not available", platforms: []string{"linux/amd64", "windows(10.0.17763)/amd64"}, target: "windows(10.0.26100)/amd64", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.New()
+			var manifests []ocispec.Descriptor
+			for i, platformStr := range tt.platforms {
+				// Distinct configs give distinct manifests
+				config := ArtifactConfig{Artifact: &ArtifactMetadata{SourceFolder: fmt.Sprintf("folder-%d", i)}}
+				var platform *ocispec.Platform
+				if platformStr != "" {
+					parsed, err := utils.NewPlatform(platformStr)
+					if err != nil {
+						t.Fatal(err)
+					}
+					oci := parsed.OCI()
+					platform = &oci
+				}
+				if tt.inConfig && platform != nil {
+					config = config.ForPlatform(platform)
+					platform = nil
+				}
+				manifest := pushTestManifest(t, store, OCIManifestMediaType, OCIConfigMediaType, config, OCILayerMediaType, nil)
+				manifest.Platform = platform
+				manifests = append(manifests, manifest)
+			}
+			indexDesc := pushTestIndex(t, store, manifests...)
+
+			target, err := utils.NewPlatform(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := SelectPlatformManifest(context.Background(), store, indexDesc, target)
+			if tt.want == -1 {
+				if err == nil {
+					t.Errorf("SelectPlatformManifest() = %s, want an error", got.Digest)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelectPlatformManifest() error = %v", err)
+			}
+			if got.Digest != manifests[tt.want].Digest {
+				t.Errorf("SelectPlatformManifest() = manifest %s, want manifest %d (%s)", got.Digest, tt.want, tt.platforms[tt.want])
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package artifact
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"educates-artifact-cli/pkg/utils"
+)
+
+// PushResult describes a pushed artifact, so that pipelines can reference it by digest
+type PushResult struct {
+	// Reference the artifact was pushed to
+	Reference string `json:"reference" yaml:"reference"`
+	// Immutable reference of the artifact, e.g. 'ghcr.io/my-user/my-app@sha256:...'
+	DigestReference string        `json:"digest_reference" yaml:"digest_reference"`
+	Digest          digest.Digest `json:"digest" yaml:"digest"`
+	MediaType       string        `json:"media_type" yaml:"media_type"`
+	Size            int64         `json:"size" yaml:"size"`
+	Tags            []string      `json:"tags" yaml:"tags"`
+	// Manifests of the index, or the single manifest pushed
+	Manifests []PushedManifest `json:"manifests" yaml:"manifests"`
+	// True when nothing was pushed
+	DryRun bool `json:"dry_run" yaml:"dry_run"`
+}
+
+// PushedManifest describes a manifest of a pushed artifact
+type PushedManifest struct {
+	Digest    digest.Digest `json:"digest" yaml:"digest"`
+	MediaType string        `json:"media_type" yaml:"media_type"`
+	Size      int64         `json:"size" yaml:"size"`
+	// Platform of the manifest in an index, empty for platform independent manifests
+	Platform string        `json:"platform,omitempty" yaml:"platform,omitempty"`
+	Layers   []PushedLayer `json:"layers" yaml:"layers"`
+}
+
+// PushedLayer describes a layer of a pushed manifest
+type PushedLayer struct {
+	Digest    digest.Digest `json:"digest" yaml:"digest"`
+	MediaType string        `json:"media_type" yaml:"media_type"`
+	Size      int64         `json:"size" yaml:"size"`
+}
+
+// Result describes the artifact pushed with the uploader, whose root manifest or index is rootDesc
+func (u *Uploader) Result(rootDesc ocispec.Descriptor, tags []string) (*PushResult, error) {
+	result := &PushResult{
+		Reference:       u.ref.String(),
+		DigestReference: fmt.Sprintf("%s/%s@%s", u.ref.Registry, u.ref.Repository, rootDesc.Digest),
+		Digest:          rootDesc.Digest,
+		MediaType:       rootDesc.MediaType,
+		Size:            rootDesc.Size,
+		Tags:            tags,
+		DryRun:          u.dryRun,
+	}
+
+	manifestDescs := []ocispec.Descriptor{rootDesc}
+	if IsIndexMediaType(rootDesc.MediaType) {
+		var index ocispec.Index
+		if err := u.unmarshalDocument(rootDesc, &index); err != nil {
+			return nil, err
+		}
+		manifestDescs = index.Manifests
+	}
+
+	for _, manifestDesc := range manifestDescs {
+		var manifest ocispec.Manifest
+		if err := u.unmarshalDocument(manifestDesc, &manifest); err != nil {
+			return nil, err
+		}
+		pushed := PushedManifest{
+			Digest:    manifestDesc.Digest,
+			MediaType: manifestDesc.MediaType,
+			Size:      manifestDesc.Size,
+		}
+		if manifestDesc.Platform != nil {
+			pushed.Platform = utils.PlatformFromOCI(*manifestDesc.Platform).String()
+		}
+		for _, layer := range manifest.Layers {
+			pushed.Layers = append(pushed.Layers, PushedLayer{
+				Digest:    layer.Digest,
+				MediaType: layer.MediaType,
+				Size:      layer.Size,
+			})
+		}
+		result.Manifests = append(result.Manifests, pushed)
+	}
+	return result, nil
+}
+
+// unmarshalDocument parses a manifest or index pushed with the uploader
+func (u *Uploader) unmarshalDocument(desc ocispec.Descriptor, v any) error {
+	data, ok := u.documents[desc.Digest]
+	if !ok {
+		return fmt.Errorf("manifest %s was not pushed", desc.Digest)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal manifest %s: %w", desc.Digest, err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package artifact
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestUploaderResult(t *testing.T) {
+	tests := []struct {
+		name          string
+		platforms     []string
+		dryRun        bool
+		wantPlatforms []string
+	}{
+		{name: "single manifest", platforms: []string{"linux/amd64"}, wantPlatforms: []string{""}},
+		{name: "index", platforms: []string{"linux/amd64", "linux/arm64"}, wantPlatforms: []string{"linux/amd64", "linux/arm64"}},
+		{name: "dry run", platforms: []string{"linux/amd64", "linux/arm64"}, dryRun: true, wantPlatforms: []string{"linux/amd64", "linux/arm64"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploader := newUploader(memory.New(), testUploadRef, tt.dryRun, &bytes.Buffer{})
+			a := pushTestArtifact(t, uploader, tt.platforms...)
+			tags := []string{"1.0.0", "latest"}
+
+			result, err := uploader.Result(a.root, tags)
+			if err != nil {
+				t.Fatalf("Result() error = %v", err)
+			}
+
+			if result.Reference != "registry.io/org/app:1.0.0" {
+				t.Errorf("Reference = %s, want registry.io/org/app:1.0.0", result.Reference)
+			}
+			if want := "registry.io/org/app@" + a.root.Digest.String(); result.DigestReference != want {
+				t.Errorf("DigestReference = %s, want %s", result.DigestReference, want)
+			}
+			if result.Digest != a.root.Digest || result.MediaType != a.root.MediaType || result.Size != a.root.Size {
+				t.Errorf("root = %s %s %d, want %s %s %d", result.Digest, result.MediaType, result.Size, a.root.Digest, a.root.MediaType, a.root.Size)
+			}
+			if !reflect.DeepEqual(result.Tags, tags) {
+				t.Errorf("Tags = %v, want %v", result.Tags, tags)
+			}
+			if result.DryRun != tt.dryRun {
+				t.Errorf("DryRun = %v, want %v", result.DryRun, tt.dryRun)
+			}
+
+			if len(result.Manifests) != len(a.manifests) {
+				t.Fatalf("Result() has %d manifests, want %d", len(result.Manifests), len(a.manifests))
+			}
+			for i, manifest := range result.Manifests {
+				want := a.manifests[i]
+				if manifest.Digest != want.Digest || manifest.MediaType != want.MediaType || manifest.Size != want.Size {
+					t.Errorf("manifest %d = %s %s %d, want %s %s %d", i, manifest.Digest, manifest.MediaType, manifest.Size, want.Digest, want.MediaType, want.Size)
+				}
+				if manifest.Platform != tt.wantPlatforms[i] {
+					t.Errorf("manifest %d platform = %q, want %q", i, manifest.Platform, tt.wantPlatforms[i])
+				}
+				var wantLayers []PushedLayer
+				for _, layer := range a.layers[i] {
+					wantLayers = append(wantLayers, PushedLayer{Digest: layer.Digest, MediaType: layer.MediaType, Size: layer.Size})
+				}
+				if !reflect.DeepEqual(manifest.Layers, wantLayers) {
+					t.Errorf("manifest %d layers = %v, want %v", i, manifest.Layers, wantLayers)
+				}
+			}
+		})
+	}
+}
+
+func TestUploaderResultNotPushed(t *testing.T) {
+	uploader := newUploader(memory.New(), testUploadRef, false, &bytes.Buffer{})
+	a := pushTestArtifact(t, memory.New(), "linux/amd64")
+	if _, err := uploader.Result(a.root, nil); err == nil {
+		t.Error("Result() error = nil, want an error for a manifest the uploader did not push")
+	}
+}
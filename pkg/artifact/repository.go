@@ -3,12 +3,14 @@ package artifact
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"strings"
 
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/retry"
 )
 
 // RepositoryRef represents a repository reference with optional authentication
@@ -95,6 +97,14 @@ func (r *RepositoryRef) Authenticate(ctx context.Context) (*remote.Repository, e
 		repo.PlainHTTP = true
 	}
 
+	// Registries returning no or unknown manifest media types are supported, see manifestTransport
+	client := &http.Client{Transport: &manifestTransport{base: retry.NewTransport(nil)}}
+	repo.Client = &auth.Client{
+		Client: client,
+		Header: auth.DefaultClient.Header,
+		Cache:  auth.DefaultCache,
+	}
+
 	if r.HasAuth() {
 		// Set up authentication if credentials are provided
 		cred := auth.Credential{
@@ -106,7 +116,7 @@ func (r *RepositoryRef) Authenticate(ctx context.Context) (*remote.Repository, e
 		registryHost := extractRegistryHost(r.URL)
 
 		authClient := &auth.Client{
-			Client:     nil, // Use default client
+			Client:     client,
 			Cache:      auth.NewCache(),
 			Credential: auth.StaticCredential(registryHost, cred),
 		}
@@ -0,0 +1,120 @@
+package artifact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"oras.land/oras-go/v2/registry"
+)
+
+// DefaultTag is the tag of a pushed artifact whose reference has no tag, when no other tag is requested
+const DefaultTag = "latest"
+
+// semverTagPattern matches the tags holding a release version, e.g. '1.4.2' or 'v1.4.2-rc.1'
+var semverTagPattern = regexp.MustCompile(`^(v?)(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(-[0-9A-Za-z.-]+)?$`)
+
+// PushTags returns the tags applied to an artifact pushed to the given reference: the tag of the reference,
+// the extra tags, then the floating tags derived from the semantic versions when requested. Floating tags
+// are only moved by the latest release of their line among the existing tags of the repository and the
+// pushed tags. Duplicates are removed. A reference without tag is tagged 'latest', unless extra tags are given.
+func PushTags(ref string, extraTags []string, floating bool, existing []string) ([]string, error) {
+	parsed, err := registry.ParseReference(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %s: %w", ref, err)
+	}
+
+	var tags []string
+	switch {
+	case parsed.Reference == "":
+		if len(extraTags) == 0 {
+			tags = append(tags, DefaultTag)
+		}
+	case parsed.ValidateReferenceAsDigest() == nil:
+		if len(extraTags) == 0 {
+			return nil, fmt.Errorf("reference %s is a digest, a tag is required to push", ref)
+		}
+	default:
+		tags = append(tags, parsed.Reference)
+	}
+
+	for _, tag := range extraTags {
+		if err := (registry.Reference{Reference: tag}).ValidateReferenceAsTag(); err != nil {
+			return nil, fmt.Errorf("invalid tag %q: tags are made of up to 128 letters, digits, '_', '.' and '-', and cannot start with '.' or '-'", tag)
+		}
+		tags = append(tags, tag)
+	}
+
+	if floating {
+		releases := append(append([]string{}, existing...), tags...)
+		for _, tag := range tags {
+			tags = append(tags, FloatingTags(tag, releases)...)
+		}
+	}
+
+	var unique []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		if !seen[tag] {
+			seen[tag] = true
+			unique = append(unique, tag)
+		}
+	}
+	return unique, nil
+}
+
+// FloatingTags returns the tags following the latest release of a major and minor version, e.g. '1.4' and '1'
+// for '1.4.2', keeping the 'v' prefix if any. A floating tag is left out when the other releases hold a
+// higher version of its line, e.g. '1' when pushing '1.3.9' after '1.4.2', so that it never moves back.
+// Pre-releases and tags that are not semantic versions have none.
+func FloatingTags(tag string, releases []string) []string {
+	version, ok := parseRelease(tag)
+	if !ok {
+		return nil
+	}
+	latestMinor, latestMajor := true, true
+	for _, release := range releases {
+		other, ok := parseRelease(release)
+		if !ok || other.prefix != version.prefix || other.major != version.major {
+			continue
+		}
+		if compareVersionNumbers(other.minor, version.minor) > 0 {
+			latestMajor = false
+		}
+		if other.minor == version.minor && compareVersionNumbers(other.patch, version.patch) > 0 {
+			latestMinor = false
+			latestMajor = false
+		}
+	}
+
+	var floating []string
+	if latestMinor {
+		floating = append(floating, version.prefix+version.major+"."+version.minor)
+	}
+	if latestMajor {
+		floating = append(floating, version.prefix+version.major)
+	}
+	return floating
+}
+
+// release is a semantic version tag without pre-release
+type release struct {
+	prefix, major, minor, patch string
+}
+
+// parseRelease parses a semantic version tag, returning false for pre-releases and other tags
+func parseRelease(tag string) (release, bool) {
+	match := semverTagPattern.FindStringSubmatch(tag)
+	if match == nil || match[5] != "" {
+		return release{}, false
+	}
+	return release{prefix: match[1], major: match[2], minor: match[3], patch: match[4]}, true
+}
+
+// compareVersionNumbers compares two version numbers without leading zeros, whatever their size
+func compareVersionNumbers(a, b string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
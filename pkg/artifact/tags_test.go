@@ -0,0 +1,80 @@
+package artifact
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFloatingTags(t *testing.T) {
+	tests := []struct {
+		tag      string
+		releases []string
+		want     []string
+	}{
+		{tag: "1.4.2", want: []string{"1.4", "1"}},
+		{tag: "v1.4.2", want: []string{"v1.4", "v1"}},
+		{tag: "0.0.1", want: []string{"0.0", "0"}},
+		{tag: "10.20.30", want: []string{"10.20", "10"}},
+		{tag: "1.4.2-rc.1", want: nil},
+		{tag: "1.4", want: nil},
+		{tag: "01.4.2", want: nil},
+		{tag: "1.4.2+build", want: nil},
+		{tag: "latest", want: nil},
+		{tag: "1.4.2", releases: []string{"1.4.1", "1.3.9", "latest"}, want: []string{"1.4", "1"}},
+		{tag: "1.4.2", releases: []string{"1.4.2"}, want: []string{"1.4", "1"}},
+		{tag: "1.3.9", releases: []string{"1.4.2"}, want: []string{"1.3"}},
+		{tag: "1.4.1", releases: []string{"1.4.2"}, want: nil},
+		{tag: "1.9.0", releases: []string{"1.10.0"}, want: []string{"1.9"}},
+		{tag: "1.4.2", releases: []string{"2.0.0", "v1.5.0", "1.5.0-rc.1"}, want: []string{"1.4", "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag+" after "+strings.Join(tt.releases, ","), func(t *testing.T) {
+			if got := FloatingTags(tt.tag, tt.releases); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FloatingTags(%q, %v) = %v, want %v", tt.tag, tt.releases, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPushTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		ref       string
+		extraTags []string
+		floating  bool
+		existing  []string
+		want      []string
+		wantErr   bool
+	}{
+		{name: "tag of the reference", ref: "registry.io/app:1.0.0", want: []string{"1.0.0"}},
+		{name: "reference without tag", ref: "registry.io/app", want: []string{DefaultTag}},
+		{name: "reference without tag and extra tags", ref: "registry.io/app", extraTags: []string{"dev"}, want: []string{"dev"}},
+		{name: "extra tags", ref: "registry.io/app:1.0.0", extraTags: []string{"latest", "stable"}, want: []string{"1.0.0", "latest", "stable"}},
+		{name: "floating tags", ref: "registry.io/app:v1.4.2", extraTags: []string{"latest"}, floating: true, want: []string{"v1.4.2", "latest", "v1.4", "v1"}},
+		{name: "floating tags of a pre-release", ref: "registry.io/app:1.4.2-rc.1", floating: true, want: []string{"1.4.2-rc.1"}},
+		{name: "floating tags of an older patch", ref: "registry.io/app:1.3.9", floating: true, existing: []string{"1.3.8", "1.4.2", "1.4", "1"}, want: []string{"1.3.9", "1.3"}},
+		{name: "floating tags of pushed releases", ref: "registry.io/app:1.4.2", extraTags: []string{"1.3.9"}, floating: true, want: []string{"1.4.2", "1.3.9", "1.4", "1", "1.3"}},
+		{name: "duplicate tags", ref: "registry.io/app:1.4.2", extraTags: []string{"1.4", "1.4.2"}, floating: true, want: []string{"1.4.2", "1.4", "1"}},
+		{name: "digest with extra tags", ref: "registry.io/app@sha256:" + testDigestHex, extraTags: []string{"1.0.0"}, want: []string{"1.0.0"}},
+		{name: "digest without tag", ref: "registry.io/app@sha256:" + testDigestHex, wantErr: true},
+		{name: "invalid extra tag", ref: "registry.io/app:1.0.0", extraTags: []string{"-bad"}, wantErr: true},
+		{name: "invalid reference", ref: "registry.io/App:1.0.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PushTags(tt.ref, tt.extraTags, tt.floating, tt.existing)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PushTags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PushTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testDigestHex is a valid sha256 digest, for references by digest
+const testDigestHex = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
@@ -0,0 +1,81 @@
+package artifact
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"educates-artifact-cli/pkg/utils"
+)
+
+// maxManifestBytes is the size of the manifests read to detect their media type, the limit of oras
+const maxManifestBytes = 4 * 1024 * 1024
+
+// manifestTransport fills in the media type of the manifests and indexes served by registries that return
+// no Content-Type or an unknown one, using the content of the manifest. Without it, oras refuses these
+// responses, so the artifact type of such manifests cannot be detected and they cannot be pulled.
+type manifestTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *manifestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK || !strings.Contains(req.URL.Path, "/manifests/") {
+		return resp, err
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil &&
+		(IsIndexMediaType(mediaType) || IsManifestMediaType(mediaType)) {
+		return resp, nil
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestBytes+1))
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		// The content is read again by oras, which refuses the manifests larger than the limit
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+		if len(data) <= maxManifestBytes {
+			setManifestMediaType(req, resp, data)
+		}
+	case http.MethodHead:
+		// The content is needed to detect the media type
+		getReq := req.Clone(req.Context())
+		getReq.Method = http.MethodGet
+		getResp, err := t.base.RoundTrip(getReq)
+		if err != nil || getResp.StatusCode != http.StatusOK {
+			if err == nil {
+				getResp.Body.Close()
+			}
+			return resp, nil
+		}
+		defer getResp.Body.Close()
+		data, err := io.ReadAll(io.LimitReader(getResp.Body, maxManifestBytes))
+		if err != nil {
+			return resp, nil
+		}
+		setManifestMediaType(req, resp, data)
+	}
+	return resp, nil
+}
+
+// readCloser reads from a reader and closes the original body of a response
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// setManifestMediaType sets the Content-Type of a manifest response from the manifest content
+func setManifestMediaType(req *http.Request, resp *http.Response, data []byte) {
+	mediaType, err := mediaTypeFromContent(data)
+	if err != nil {
+		return
+	}
+	utils.VerbosePrintf("Registry returned the Content-Type %q for %s, using %s from the content\n", resp.Header.Get("Content-Type"), req.URL.Path, mediaType)
+	resp.Header.Set("Content-Type", mediaType)
+}
@@ -0,0 +1,66 @@
+package artifact
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestManifestTransport(t *testing.T) {
+	index := `{"schemaVersion":2,"manifests":[]}`
+	manifest := `{"schemaVersion":2,"config":{},"layers":[]}`
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{name: "missing index media type", method: http.MethodGet, path: "/v2/a/manifests/1", body: index, want: OCIIndexMediaType},
+		{name: "unknown manifest media type", method: http.MethodGet, path: "/v2/a/manifests/1", contentType: "application/octet-stream", body: manifest, want: OCIManifestMediaType},
+		{name: "media type from the content", method: http.MethodGet, path: "/v2/a/manifests/1", contentType: "application/json", body: `{"mediaType":"` + DockerManifestMediaType + `","config":{}}`, want: DockerManifestMediaType},
+		{name: "missing media type on HEAD", method: http.MethodHead, path: "/v2/a/manifests/1", body: index, want: OCIIndexMediaType},
+		{name: "known media type kept", method: http.MethodGet, path: "/v2/a/manifests/1", contentType: DockerIndexMediaType, body: index, want: DockerIndexMediaType},
+		{name: "blobs untouched", method: http.MethodGet, path: "/v2/a/blobs/sha256:0", contentType: "application/octet-stream", body: manifest, want: "application/octet-stream"},
+		{name: "invalid content untouched", method: http.MethodGet, path: "/v2/a/manifests/1", contentType: "text/plain", body: "not json", want: "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Without Content-Type, the server would sniff one from the content
+				w.Header()["Content-Type"] = nil
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: &manifestTransport{base: http.DefaultTransport}}
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if got := resp.Header.Get("Content-Type"); got != tt.want {
+				t.Errorf("Content-Type = %q, want %q", got, tt.want)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.method == http.MethodGet && string(body) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+
+	"educates-artifact-cli/pkg/utils"
+)
+
+// Uploader pushes the content of an artifact to a repository, skipping the content the registry already
+// holds. When a repository to mount from is set, blobs are mounted from it with the cross-repository mount
+// endpoint of the registry, and uploaded when the registry cannot mount them. It counts the bytes actually
+// transferred for the push summary. A dry-run uploader prints the content instead, without contacting
+// the registry.
+type Uploader struct {
+	// target is the repository pushed to, nil in a dry run. Blobs are only mounted, and tags listed, when
+	// it supports it, as a remote repository does.
+	target    oras.Target
+	ref       registry.Reference
+	mountFrom string
+	dryRun    bool
+	// out receives the dry-run content and the tagging messages
+	out io.Writer
+	// Manifests and indexes pushed, by digest, to describe the pushed artifact
+	documents map[digest.Digest][]byte
+
+	// Transferred is the number of bytes uploaded, in TransferredCount pushes
+	Transferred      int64
+	TransferredCount int
+	// Skipped is the number of bytes already held by the repository, in SkippedCount descriptors
+	Skipped      int64
+	SkippedCount int
+	// Mounted is the number of bytes mounted from the other repository, in MountedCount blobs
+	Mounted      int64
+	MountedCount int
+}
+
+// NewPushUploader returns the uploader of a push to the repository reference. Unless the push is a dry run,
+// it connects to the repository, which is also returned. In a dry run the returned repository is nil.
+func NewPushUploader(ctx context.Context, repoRef *RepositoryRef, opts Options) (*Uploader, *remote.Repository, error) {
+	if opts.DryRun {
+		uploader, err := NewDryRunUploader(repoRef.String(), opts.ProgressWriter())
+		return uploader, nil, err
+	}
+
+	repo, err := repoRef.Authenticate(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create repository client: %w", err)
+	}
+	uploader, err := NewUploader(repo, opts.MountFrom, opts.ProgressWriter())
+	if err != nil {
+		return nil, nil, err
+	}
+	return uploader, repo, nil
+}
+
+// NewDryRunUploader creates an uploader printing to out the descriptors pushed to the given reference, and
+// the JSON documents (configs, manifests and indexes) exactly as they would be uploaded
+func NewDryRunUploader(ref string, out io.Writer) (*Uploader, error) {
+	parsedRef, err := registry.ParseReference(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %s: %w", ref, err)
+	}
+	return newUploader(nil, parsedRef, true, out), nil
+}
+
+// NewUploader creates an uploader pushing to the given repository, reporting the applied tags to out.
+// mountFrom is a repository of the same registry holding some of the blobs (e.g. 'ghcr.io/my-user/my-app'),
+// or empty.
+func NewUploader(repo *remote.Repository, mountFrom string, out io.Writer) (*Uploader, error) {
+	uploader := newUploader(repo, repo.Reference, false, out)
+	if mountFrom == "" {
+		return uploader, nil
+	}
+
+	fromRef, err := registry.ParseReference(mountFrom)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository to mount blobs from %s: %w", mountFrom, err)
+	}
+	if fromRef.Registry != repo.Reference.Registry {
+		return nil, fmt.Errorf("blobs can only be mounted from a repository of registry %s, %s is on %s", repo.Reference.Registry, mountFrom, fromRef.Registry)
+	}
+	if fromRef.Repository != repo.Reference.Repository {
+		uploader.mountFrom = fromRef.Repository
+	}
+	return uploader, nil
+}
+
+// newUploader creates an uploader pushing to the target, whose reference is ref
+func newUploader(target oras.Target, ref registry.Reference, dryRun bool, out io.Writer) *Uploader {
+	return &Uploader{target: target, ref: ref, dryRun: dryRun, out: out, documents: make(map[digest.Digest][]byte)}
+}
+
+// Push pushes content unless the repository already holds it, which is intended for manifests and indexes
+// too: they are addressed by digest, and tagged separately by Tag. Only blobs are mounted, manifests and
+// indexes are uploaded when missing. It implements content.Pusher.
+func (u *Uploader) Push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	if IsIndexMediaType(expected.MediaType) || IsManifestMediaType(expected.MediaType) {
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return fmt.Errorf("failed to read manifest %s: %w", expected.Digest, err)
+		}
+		u.documents[expected.Digest] = data
+		content = bytes.NewReader(data)
+	}
+
+	if u.dryRun {
+		return u.print(expected, content)
+	}
+
+	exists, err := u.target.Exists(ctx, expected)
+	if err != nil {
+		return fmt.Errorf("failed to check whether %s exists: %w", expected.Digest, err)
+	}
+	if exists {
+		utils.VerbosePrintf("Skipping %s, already in the repository\n", expected.Digest)
+		u.Skipped += expected.Size
+		u.SkippedCount++
+		return nil
+	}
+
+	mounter, canMount := u.target.(registry.Mounter)
+	if canMount && u.mountFrom != "" && !IsIndexMediaType(expected.MediaType) && !IsManifestMediaType(expected.MediaType) {
+		// The content is only read when the registry cannot mount the blob and starts an upload instead
+		uploaded := false
+		getContent := func() (io.ReadCloser, error) {
+			uploaded = true
+			return io.NopCloser(content), nil
+		}
+		if err := mounter.Mount(ctx, expected, u.mountFrom, getContent); err != nil {
+			return err
+		}
+		if !uploaded {
+			utils.VerbosePrintf("Mounted %s from %s\n", expected.Digest, u.mountFrom)
+			u.Mounted += expected.Size
+			u.MountedCount++
+			return nil
+		}
+	} else if err := u.target.Push(ctx, expected, content); err != nil {
+		return err
+	}
+	u.Transferred += expected.Size
+	u.TransferredCount++
+	return nil
+}
+
+// print prints a descriptor pushed in a dry run, followed by its content unless it is a layer
+func (u *Uploader) print(expected ocispec.Descriptor, content io.Reader) error {
+	kind := "layer"
+	switch {
+	case IsIndexMediaType(expected.MediaType):
+		kind = "index"
+	case IsManifestMediaType(expected.MediaType):
+		kind = "manifest"
+	case strings.HasSuffix(expected.MediaType, "json"):
+		kind = "config"
+	}
+	fmt.Fprintf(u.out, "\n--- %s %s (%s, %d bytes)\n", kind, expected.Digest, expected.MediaType, expected.Size)
+	if kind != "layer" {
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return fmt.Errorf("failed to read %s %s: %w", kind, expected.Digest, err)
+		}
+		fmt.Fprintf(u.out, "%s\n", data)
+	}
+
+	u.Transferred += expected.Size
+	u.TransferredCount++
+	return nil
+}
+
+// Tag tags the root descriptor of the pushed artifact with every tag, reporting each of them
+func (u *Uploader) Tag(ctx context.Context, rootDesc ocispec.Descriptor, tags []string) error {
+	for _, tag := range tags {
+		if u.dryRun {
+			fmt.Fprintf(u.out, "Would tag %s/%s:%s\n", u.ref.Registry, u.ref.Repository, tag)
+			continue
+		}
+		if err := u.target.Tag(ctx, rootDesc, tag); err != nil {
+			return fmt.Errorf("failed to tag root descriptor with %s: %w", tag, err)
+		}
+		fmt.Fprintf(u.out, "Tagged %s/%s:%s\n", u.ref.Registry, u.ref.Repository, tag)
+	}
+	return nil
+}
+
+// ExistingTags returns the tags of the repository, which decide whether the floating tags move. It returns
+// none in a dry run, which does not contact the registry, and for a repository that does not exist yet.
+func (u *Uploader) ExistingTags(ctx context.Context) ([]string, error) {
+	lister, ok := u.target.(registry.TagLister)
+	if u.dryRun || !ok {
+		return nil, nil
+	}
+	var tags []string
+	err := lister.Tags(ctx, "", func(page []string) error {
+		tags = append(tags, page...)
+		return nil
+	})
+	var errResp *errcode.ErrorResponse
+	if errors.As(err, &errResp) && errResp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tags of the repository: %w", err)
+	}
+	return tags, nil
+}
+
+// Summary describes the bytes transferred by the pushes, e.g. 'Transferred 1024 bytes (2 uploaded, 3 already
+// in the registry)'
+func (u *Uploader) Summary() string {
+	if u.dryRun {
+		return fmt.Sprintf("Dry run: %d bytes in %d descriptors would be pushed, the registry was not contacted", u.Transferred, u.TransferredCount)
+	}
+	details := []string{fmt.Sprintf("%d uploaded", u.TransferredCount)}
+	if u.SkippedCount > 0 {
+		details = append(details, fmt.Sprintf("%d already in the registry (%d bytes)", u.SkippedCount, u.Skipped))
+	}
+	if u.MountedCount > 0 {
+		details = append(details, fmt.Sprintf("%d mounted from %s (%d bytes)", u.MountedCount, u.mountFrom, u.Mounted))
+	}
+	return fmt.Sprintf("Transferred %d bytes (%s)", u.Transferred, strings.Join(details, ", "))
+}
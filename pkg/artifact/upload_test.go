@@ -0,0 +1,176 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/registry"
+)
+
+// testArtifact is an artifact pushed by pushTestArtifact
+type testArtifact struct {
+	root ocispec.Descriptor
+	// Every descriptor pushed: layers, configs, manifests and the index
+	pushed []ocispec.Descriptor
+	// Manifests of the artifact, in the index order, and the layers of each of them
+	manifests []ocispec.Descriptor
+	layers    [][]ocispec.Descriptor
+}
+
+// pushTestArtifact pushes an artifact with a manifest per platform, grouped in an index when there are
+// several platforms, the way the artifacts push them
+func pushTestArtifact(t *testing.T, pusher content.Pusher, platforms ...string) testArtifact {
+	t.Helper()
+	var a testArtifact
+	push := func(mediaType string, data []byte) ocispec.Descriptor {
+		desc := content.NewDescriptorFromBytes(mediaType, data)
+		if err := pusher.Push(context.Background(), desc, bytes.NewReader(data)); err != nil {
+			t.Fatalf("Push(%s) error = %v", mediaType, err)
+		}
+		a.pushed = append(a.pushed, desc)
+		return desc
+	}
+	pushJSON := func(mediaType string, v any) ocispec.Descriptor {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return push(mediaType, data)
+	}
+
+	for _, platform := range platforms {
+		layer := push(OCILayerMediaType, []byte("layer of "+platform))
+		config := pushJSON(OCIConfigMediaType, map[string]string{"platform": platform})
+		manifest := pushJSON(OCIManifestMediaType, ocispec.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: OCIManifestMediaType,
+			Config:    config,
+			Layers:    []ocispec.Descriptor{layer},
+		})
+		if len(platforms) > 1 {
+			osName, arch, _ := strings.Cut(platform, "/")
+			manifest.Platform = &ocispec.Platform{OS: osName, Architecture: arch}
+		}
+		a.manifests = append(a.manifests, manifest)
+		a.layers = append(a.layers, []ocispec.Descriptor{layer})
+	}
+
+	a.root = a.manifests[0]
+	if len(platforms) > 1 {
+		a.root = pushJSON(OCIIndexMediaType, ocispec.Index{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: OCIIndexMediaType,
+			Manifests: a.manifests,
+		})
+	}
+	return a
+}
+
+// testUploadRef is the reference of the repository the tests push to
+var testUploadRef = registry.Reference{Registry: "registry.io", Repository: "org/app", Reference: "1.0.0"}
+
+func TestUploaderPush(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	var out bytes.Buffer
+	uploader := newUploader(store, testUploadRef, false, &out)
+	a := pushTestArtifact(t, uploader, "linux/amd64", "linux/arm64")
+	for _, desc := range a.pushed {
+		if exists, err := store.Exists(ctx, desc); err != nil || !exists {
+			t.Errorf("%s %s not pushed to the store", desc.MediaType, desc.Digest)
+		}
+	}
+	if uploader.TransferredCount != len(a.pushed) || uploader.SkippedCount != 0 {
+		t.Errorf("uploaded %d and skipped %d descriptors, want %d uploaded", uploader.TransferredCount, uploader.SkippedCount, len(a.pushed))
+	}
+
+	if err := uploader.Tag(ctx, a.root, []string{"1.0.0", "latest"}); err != nil {
+		t.Fatalf("Tag() error = %v", err)
+	}
+	for _, tag := range []string{"1.0.0", "latest"} {
+		desc, err := store.Resolve(ctx, tag)
+		if err != nil || desc.Digest != a.root.Digest {
+			t.Errorf("tag %s resolves to %s (%v), want %s", tag, desc.Digest, err, a.root.Digest)
+		}
+		if !strings.Contains(out.String(), "Tagged registry.io/org/app:"+tag+"\n") {
+			t.Errorf("output %q does not report tag %s", out.String(), tag)
+		}
+	}
+
+	// Pushing the same artifact again skips everything the store already holds
+	again := newUploader(store, testUploadRef, false, &out)
+	pushTestArtifact(t, again, "linux/amd64", "linux/arm64")
+	if again.TransferredCount != 0 || again.SkippedCount != len(a.pushed) {
+		t.Errorf("uploaded %d and skipped %d descriptors, want %d skipped", again.TransferredCount, again.SkippedCount, len(a.pushed))
+	}
+	if want := fmt.Sprintf("Transferred 0 bytes (0 uploaded, %d already in the registry", len(a.pushed)); !strings.HasPrefix(again.Summary(), want) {
+		t.Errorf("Summary() = %q", again.Summary())
+	}
+}
+
+func TestDryRunUploaderPushesNothing(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+
+	var out bytes.Buffer
+	uploader := newUploader(store, testUploadRef, true, &out)
+	a := pushTestArtifact(t, uploader, "linux/amd64", "linux/arm64")
+	if err := uploader.Tag(ctx, a.root, []string{"1.0.0"}); err != nil {
+		t.Fatalf("Tag() error = %v", err)
+	}
+
+	for _, desc := range a.pushed {
+		if exists, err := store.Exists(ctx, desc); err != nil || exists {
+			t.Errorf("%s %s pushed to the store in a dry run", desc.MediaType, desc.Digest)
+		}
+	}
+	if _, err := store.Resolve(ctx, "1.0.0"); err == nil {
+		t.Error("tag 1.0.0 applied in a dry run")
+	}
+	if tags, err := uploader.ExistingTags(ctx); err != nil || tags != nil {
+		t.Errorf("ExistingTags() = %v, %v, want no tags", tags, err)
+	}
+
+	// The output holds every descriptor, with the content of the JSON documents but not of the layers
+	printed := out.String()
+	for _, desc := range a.pushed {
+		if !strings.Contains(printed, desc.Digest.String()+" ("+desc.MediaType) {
+			t.Errorf("dry run output does not list %s %s", desc.MediaType, desc.Digest)
+		}
+	}
+	if strings.Contains(printed, "layer of linux/amd64") {
+		t.Error("dry run output holds the content of a layer")
+	}
+	if !strings.Contains(printed, `"platform":"linux/arm64"`) {
+		t.Error("dry run output does not hold the content of the configs")
+	}
+	if !strings.Contains(printed, "Would tag registry.io/org/app:1.0.0\n") {
+		t.Errorf("dry run output %q does not report the tag", printed)
+	}
+	if uploader.TransferredCount != len(a.pushed) {
+		t.Errorf("TransferredCount = %d, want %d", uploader.TransferredCount, len(a.pushed))
+	}
+}
+
+func TestNewDryRunUploader(t *testing.T) {
+	uploader, err := NewDryRunUploader("registry.io/org/app:1.0.0", &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("NewDryRunUploader() error = %v", err)
+	}
+	if uploader.target != nil || !uploader.dryRun || uploader.ref != testUploadRef {
+		t.Errorf("NewDryRunUploader() = %+v, want a dry run of %s", uploader, testUploadRef)
+	}
+
+	if _, err := NewDryRunUploader("registry.io/Org/app", &bytes.Buffer{}); err == nil {
+		t.Error("NewDryRunUploader() error = nil, want an invalid reference error")
+	}
+}
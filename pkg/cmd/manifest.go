@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"educates-artifact-cli/pkg/artifact"
 	"educates-artifact-cli/pkg/utils"
@@ -19,7 +20,6 @@ type ManifestCmdOpts struct {
 	Insecure     bool
 	Timeout      string
 	OutputFormat string
-	// ArtifactType ArtifactType
 }
 
 const (
@@ -31,19 +31,15 @@ const (
 // NewManifestCmd creates the 'manifest' command
 func NewManifestCmd() *cobra.Command {
 	var opts ManifestCmdOpts
-	// opts.ArtifactType = DefaultArtifactType
 	opts.OutputFormat = OutputFormatTable
 
 	cmd := &cobra.Command{
 		Use:   "describe <repository>",
 		Short: "Manifest an OCI artifact manifest",
 		Long:  `Manifest provides human-readable information about an OCI artifact, including platform support, artifact type, and layer information.`,
-		Example: `  # Manifest an artifact (auto-detect type)
+		Example: `  # Manifest an artifact (artifact type is auto-detected)
   artifact-cli describe ghcr.io/my-user/my-app:1.0.0
 
-  # Manifest with specific artifact type
-  artifact-cli describe ghcr.io/my-user/my-app:1.0.0 --as oci
-
   # Output in JSON format
   artifact-cli describe ghcr.io/my-user/my-app:1.0.0 --output json
 
@@ -61,7 +57,6 @@ func NewManifestCmd() *cobra.Command {
 		},
 	}
 
-	// cmd.Flags().Var(&opts.ArtifactType, "as", "Type of artifact to describe (oci, imgpkg, educates). Auto-detected if not specified")
 	cmd.Flags().StringVarP(&opts.OutputFormat, "output", "o", OutputFormatTable, "Output format (table, json, yaml)")
 	cmd.Flags().StringVarP(&opts.Timeout, "timeout", "t", "", "Timeout for the operation (e.g., '30s', '5m', '1h'). Defaults to 5m")
 	cmd.Flags().StringVarP(&opts.Username, "username", "u", "", "Username for registry authentication (can also use ARTIFACT_CLI_USERNAME env var)")
@@ -124,14 +119,43 @@ func outputYAML(v any) error {
 
 func outputTable(imageMetadata artifact.ImageMetadata) error {
 	fmt.Printf("Image Ref: %s\n", imageMetadata.ImageRef)
+	fmt.Printf("Artifact Type: %s\n", imageMetadata.ArtifactType)
 	fmt.Printf("Media Type: %s\n", imageMetadata.MediaType)
 	fmt.Printf("OCI Compliant: %t\n", imageMetadata.OciCompliant)
 	fmt.Printf("Multi-Platform: %t\n", imageMetadata.MultiPlatform)
+	fmt.Printf("Artifact Manifest: %t\n", imageMetadata.ArtifactManifest)
+	if imageMetadata.OCIArtifactType != "" {
+		fmt.Printf("OCI Artifact Type: %s\n", imageMetadata.OCIArtifactType)
+	}
+	if imageMetadata.ImgpkgBundle {
+		fmt.Printf("Imgpkg Bundle: %t\n", imageMetadata.ImgpkgBundle)
+	}
+
+	if config := imageMetadata.Config; config != nil {
+		if config.Created != nil {
+			fmt.Printf("Created: %s\n", config.Created.Format(time.RFC3339))
+		}
+		if metadata := config.Artifact; metadata != nil {
+			fmt.Printf("Tool: %s %s\n", metadata.Tool, metadata.ToolVersion)
+			fmt.Printf("Source Folder: %s\n", metadata.SourceFolder)
+			fmt.Printf("File Count: %d\n", metadata.FileCount)
+			fmt.Printf("Uncompressed Size: %d bytes\n", metadata.UncompressedSize)
+			fmt.Printf("Content Digest: %s\n", metadata.ContentDigest)
+		}
+	}
 
 	if len(imageMetadata.Platforms) > 0 {
 		fmt.Printf("Platforms:\n")
 		for _, platform := range imageMetadata.Platforms {
-			fmt.Printf("  - %s (%s)\n", platform.Architecture, platform.OS)
+			architecture := platform.Architecture
+			if platform.Variant != "" {
+				architecture += "/" + platform.Variant
+			}
+			if platform.OSVersion != "" {
+				fmt.Printf("  - %s (%s %s)\n", architecture, platform.OS, platform.OSVersion)
+			} else {
+				fmt.Printf("  - %s (%s)\n", architecture, platform.OS)
+			}
 		}
 	}
 
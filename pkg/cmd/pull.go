@@ -7,35 +7,52 @@ import (
 	"github.com/spf13/cobra"
 
 	"educates-artifact-cli/pkg/artifact"
-	"educates-artifact-cli/pkg/artifact/oci"
+	"educates-artifact-cli/pkg/artifact/formats"
 	"educates-artifact-cli/pkg/utils"
 )
 
 type PullCmdOpts struct {
-	RepoRef     string
-	Username    string
-	Password    string
-	Insecure    bool
-	PlatformStr string
-	OutputDir   string
-	Timeout     string
-	// ArtifactType ArtifactType
+	RepoRef         string
+	Username        string
+	Password        string
+	Insecure        bool
+	PlatformStr     string
+	OutputDir       string
+	Timeout         string
+	ArtifactType    artifact.ArtifactType
+	ImageLayers     bool
+	SubPath         string
+	RelocateImages  bool
+	LayerMediaTypes []string
+	LayerIndexes    []int
 }
 
 // NewPullCmd creates the 'pull' command
 func NewPullCmd() *cobra.Command {
 	var opts PullCmdOpts
-	// opts.ArtifactType = DefaultArtifactType
 
 	cmd := &cobra.Command{
 		Use:   "pull <repository> -o <target_dir> [-p <platform>]",
 		Short: "Pull and extract an OCI artifact folder",
-		Example: `  # Pull the artifact matching the current system's architecture
+		Example: `  # Pull the artifact matching the current system's architecture (artifact type is auto-detected)
   artifact-cli pull ghcr.io/my-user/my-app:1.0.1 -o ./restored-app
 
   # Pull a specific platform
   artifact-cli pull ghcr.io/my-user/my-app:1.0.1 -o ./restored-app -p linux/amd64
 
+  # Pull forcing a specific artifact type
+  artifact-cli pull ghcr.io/my-user/my-app:1.0.1 -o ./restored-app --as imgpkg
+
+  # Extract the documentation of an application image, applying all its layers
+  artifact-cli pull ghcr.io/my-user/my-app-image:1.0.1 -o ./workshop/docs --image --sub-path /opt/app/docs
+
+  # Pull an imgpkg bundle, referencing the images copied with it in its images lock
+  artifact-cli pull ghcr.io/my-user/my-bundle:1.0.0 -o ./bundle --relocate-images
+
+  # Pull the sources of a Helm chart, or only the first layer of another tool's artifact
+  artifact-cli pull ghcr.io/my-user/charts/my-chart:1.0.0 -o ./chart
+  artifact-cli pull ghcr.io/my-user/my-tool-artifact:1.0.0 -o ./content --layer-index 0
+
   # Verbose pull
   artifact-cli pull ghcr.io/my-user/my-app:1.0.1 -o ./restored-app -v`,
 		Args:         cobra.ExactArgs(1),
@@ -49,7 +66,12 @@ func NewPullCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&opts.OutputDir, "output", "o", "", "Path to the target directory for extraction (required)")
 	cmd.Flags().StringVarP(&opts.PlatformStr, "platform", "p", "", "Target platform (e.g., 'linux/amd64'). If not specified, uses current system platform")
 	cmd.Flags().StringVarP(&opts.Timeout, "timeout", "t", "", "Timeout for the operation (e.g., '30s', '5m', '1h'). Defaults to 5m")
-	// cmd.Flags().Var(&opts.ArtifactType, "as", "Type of artifact to push (oci, imgpkg, educates). Defaults to oci")
+	cmd.Flags().VarP(&opts.ArtifactType, "as", "a", "Type of artifact to pull (oci, imgpkg, educates). Auto-detected if not specified")
+	cmd.Flags().BoolVarP(&opts.ImageLayers, "image", "", false, "Pull as a container image: apply every filesystem layer in order, honouring whiteouts")
+	cmd.Flags().StringVarP(&opts.SubPath, "sub-path", "", "", "Only extract this path of the artifact (e.g., '/opt/app/docs') to the target directory")
+	cmd.Flags().StringArrayVarP(&opts.LayerMediaTypes, "layer-media-type", "", nil, "Only extract the layers of this media type, regardless of their compression (can be repeated, e.g. 'application/vnd.cncf.helm.chart.content.v1.tar+gzip')")
+	cmd.Flags().IntSliceVarP(&opts.LayerIndexes, "layer-index", "", nil, "Only extract the layer at this 0-based position of the manifest (can be repeated)")
+	cmd.Flags().BoolVarP(&opts.RelocateImages, "relocate-images", "", false, "Rewrite the .imgpkg/images.yml lock of an imgpkg bundle to reference the images copied into the bundle repository")
 	cmd.Flags().StringVarP(&opts.Username, "username", "u", "", "Username for registry authentication (can also use ARTIFACT_CLI_USERNAME env var)")
 	cmd.Flags().StringVarP(&opts.Password, "password", "w", "", "Password or token for registry authentication (can also use ARTIFACT_CLI_PASSWORD env var)")
 	cmd.Flags().BoolVarP(&opts.Insecure, "insecure", "", false, "Allow insecure registry communication")
@@ -68,19 +90,26 @@ func runPull(opts PullCmdOpts) error {
 
 	repoRef := artifact.NewRepositoryRef(opts.RepoRef, opts.Username, opts.Password, opts.Insecure)
 
-	// Use default platforms if no platform is specified
-	if opts.PlatformStr == "" {
-		opts.PlatformStr = utils.GetOSPlatformStr()
-	}
+	if opts.PlatformStr != "" {
+		platforms := utils.SlicePlatforms(opts.PlatformStr)
+		if err := utils.ValidatePlatforms(platforms); err != nil {
+			return err
+		}
 
-	platforms := utils.SlicePlatforms(opts.PlatformStr)
-	if err := utils.ValidatePlatforms(platforms); err != nil {
-		return err
+		// Do some validation
+		if len(platforms) > 1 {
+			return fmt.Errorf("when pulling an OCI artifact, can only pull for one platform")
+		}
+	} else {
+		// Use the current system platform if no platform is specified. It is not validated against
+		// the supported platforms, as platform independent artifacts can be pulled on any host
+		opts.PlatformStr = utils.GetOSPlatformStr()
 	}
 
-	// Do some validation
-	if len(platforms) > 1 {
-		return fmt.Errorf("when pulling an OCI artifact, can only pull for one platform")
+	if opts.SubPath != "" {
+		if _, err := utils.NormalizeSubPath(opts.SubPath); err != nil {
+			return err
+		}
 	}
 
 	// Ensure the output directory exists
@@ -88,16 +117,29 @@ func runPull(opts PullCmdOpts) error {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	artifactOpts := artifact.Options{
+		ImageLayers:          opts.ImageLayers,
+		SubPath:              opts.SubPath,
+		RelocateBundleImages: opts.RelocateImages,
+		LayerMediaTypes:      opts.LayerMediaTypes,
+		LayerIndexes:         opts.LayerIndexes,
+	}
+
 	var artifactInstance artifact.Artifact
 
-	// switch opts.ArtifactType {
-	// case ArtifactTypeOci:
-	artifactInstance = oci.NewOciImageArtifact(repoRef, nil, opts.PlatformStr, opts.OutputDir)
-	// case ArtifactTypeImgpkg:
-	// 	artifact = imgpkg.NewImgpkgImageArtifact(repoRef, nil, opts.PlatformStr, opts.OutputDir)
-	// case ArtifactTypeEducates:
-	// 	artifact = educates.NewEducatesImageArtifact(repoRef, nil, opts.PlatformStr, opts.OutputDir)
-	// }
+	if opts.ArtifactType == "" {
+		var artifactType artifact.ArtifactType
+		artifactInstance, artifactType, err = formats.Detect(ctx, repoRef, opts.PlatformStr, opts.OutputDir, artifactOpts)
+		if err != nil {
+			return err
+		}
+		utils.VerbosePrintf("Pulling artifact as %s\n", artifactType)
+	} else {
+		artifactInstance, err = formats.New(opts.ArtifactType, repoRef, nil, opts.PlatformStr, opts.OutputDir, artifactOpts)
+		if err != nil {
+			return err
+		}
+	}
 
 	err = artifactInstance.Pull(ctx)
 	if err != nil {
@@ -111,6 +153,4 @@ func runPull(opts PullCmdOpts) error {
 	}
 
 	return nil
-
-	// return Run(repoRef, opts.PlatformStr, opts.OutputDir, opts.ArtifactType)
 }
@@ -2,44 +2,116 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/spf13/cobra"
 
 	"educates-artifact-cli/pkg/artifact"
-	"educates-artifact-cli/pkg/artifact/oci"
+	"educates-artifact-cli/pkg/artifact/formats"
+	"educates-artifact-cli/pkg/artifact/imgpkg"
 	"educates-artifact-cli/pkg/utils"
 )
 
 type PushCmdOpts struct {
-	ImageRef   string
-	Username   string
-	Password   string
-	Insecure   bool
-	Platforms  string
-	FolderPath string
-	Timeout    string
-	// ArtifactType ArtifactType
+	ImageRef  string
+	Username  string
+	Password  string
+	Insecure  bool
+	Platforms string
+	// Folder to push, or 'platform=folder' mappings for per-platform content
+	Folders      []string
+	Timeout      string
+	ArtifactType artifact.ArtifactType
+	// Push OCI 1.1 artifact manifests instead of image manifests
+	ArtifactManifest bool
+	Compression      utils.Compression
+	LayerPaths       []string
+	CopyImages       bool
+	Reproducible     bool
+	FileLayers       bool
+	IgnoreFile       string
+	IncludePaths     []string
+	ExcludePaths     []string
+	Symlinks         utils.SymlinkPolicy
+	Tags             []string
+	FloatingTags     bool
+	From             string
+	// User annotations as 'key=value', overriding those of the annotation file
+	Annotations         []string
+	AnnotationFile      string
+	StandardAnnotations bool
+	DryRun              bool
+	// Format of the push result printed on the standard output (json, yaml), none when empty
+	OutputFormat string
+	DigestFile   string
 }
 
-// const DefaultArtifactType = ArtifactTypeOci
+const DefaultArtifactType = artifact.ArtifactTypeOci
 
 // NewPushCmd creates the 'push' command
 func NewPushCmd() *cobra.Command {
 	var opts PushCmdOpts
-	// opts.ArtifactType = DefaultArtifactType
+	opts.ArtifactType = DefaultArtifactType
+	opts.Compression = utils.CompressionGzip
+	opts.Symlinks = utils.SymlinksFollow
 
 	cmd := &cobra.Command{
-		Use:   "push <repository> -f <folder> [-p <platforms>]",
+		Use:   "push <repository> -f <folder> [-p <platforms>] [--as <type>]",
 		Short: "Package and push a folder to an OCI registry",
-		Example: `  # Push a single artifact
+		Example: `  # Push a single manifest artifact (no platform selector, no index)
   artifact-cli push ghcr.io/my-user/my-app:1.0.0 -f ./app-folder
 
   # Push a multi-platform artifact
   artifact-cli push ghcr.io/my-user/my-app:1.0.1 -f ./app-folder -p linux/amd64,linux/arm64
 
+  # Push per-platform content, with a common folder shared by all the platforms
+  artifact-cli push ghcr.io/my-user/my-app:1.0.1 -f ./common -f linux/amd64=./dist/amd64 -f linux/arm64=./dist/arm64
+
+  # Push a release, also tagged with its floating major.minor and major versions and latest
+  artifact-cli push ghcr.io/my-user/my-app:1.4.2 -f ./app-folder --floating-tags --tag latest
+
+  # Push with extra annotations, in addition to the created, source, revision and version read from git
+  artifact-cli push ghcr.io/my-user/my-app:1.0.1 -f ./app-folder --annotation org.opencontainers.image.licenses=Apache-2.0 --annotation-file ./annotations.yaml
+
+  # Push a new version, mounting the blobs it shares with another repository of the registry
+  artifact-cli push ghcr.io/my-user/my-app-dev:1.4.2 -f ./app-folder --from ghcr.io/my-user/my-app
+
+  # Check the manifests and configs of a multi-platform push without contacting the registry
+  artifact-cli push ghcr.io/my-user/my-app:1.0.1 -f ./app-folder -p linux/amd64,linux/arm64 --dry-run
+
+  # Push from a pipeline, keeping the immutable reference of the artifact for the deploy steps
+  artifact-cli push ghcr.io/my-user/my-app:1.0.1 -f ./app-folder --output json --digest-file ./artifact.ref
+
   # Push an artifact with a specific artifact type
   artifact-cli push ghcr.io/my-user/my-app:1.0.1 -f ./app-folder -a imgpkg
 
+  # Push workshop content as an Educates artifact
+  artifact-cli push ghcr.io/my-user/my-workshop-files:1.0.0 -f ./workshop-folder --as educates
+
+  # Push an OCI 1.1 artifact manifest with an empty config
+  artifact-cli push ghcr.io/my-user/my-app:1.0.0 -f ./app-folder --artifact-manifest
+
+  # Push with zstd compressed layers
+  artifact-cli push ghcr.io/my-user/my-app:1.0.0 -f ./app-folder --compression zstd
+
+  # Push a folder split into several layers, so unchanged layers are not uploaded again
+  artifact-cli push ghcr.io/my-user/my-app:1.0.0 -f ./app-folder --layer workshop --layer exercises --layer assets
+
+  # Push only the workshop content of a repository, without copying it to a staging folder
+  artifact-cli push ghcr.io/my-user/my-workshop-files:1.0.0 -f . --include 'workshop/**' --include 'exercises/**' --exclude '**/*.bak'
+
+  # Push a folder storing its symlinks as symlinks, instead of the content they point to
+  artifact-cli push ghcr.io/my-user/my-app:1.0.0 -f ./app-folder --symlinks preserve
+
+  # Push every file as its own layer, to be pulled with 'oras pull'
+  artifact-cli push ghcr.io/my-user/my-files:1.0.0 -f ./files-folder --file-layers
+
+  # Push a folder holding a .imgpkg directory as an imgpkg bundle, copying the images it references
+  artifact-cli push ghcr.io/my-user/my-bundle:1.0.0 -f ./bundle-folder --as imgpkg --copy-images
+
   # Verbose push
   artifact-cli push ghcr.io/my-user/my-app:1.0.0 -f ./app-folder -v`,
 
@@ -51,10 +123,29 @@ func NewPushCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&opts.FolderPath, "folder", "f", "", "Path to the folder to package and push (required)")
-	cmd.Flags().StringVarP(&opts.Platforms, "platforms", "p", "", "A comma-separated list of platforms (e.g., 'linux/amd64,linux/arm64')")
+	cmd.Flags().StringArrayVarP(&opts.Folders, "folder", "f", nil, "Path to the folder to package and push (required). Repeat as 'platform=path' (e.g., 'linux/amd64=./dist/amd64') to push per-platform content, with an optional plain path for the content common to all platforms")
+	cmd.Flags().StringVarP(&opts.Platforms, "platforms", "p", "", "A comma-separated list of platforms (e.g., 'linux/amd64,linux/arm64'). If not specified, a single manifest without platform selector is pushed")
 	cmd.Flags().StringVarP(&opts.Timeout, "timeout", "t", "", "Timeout for the operation (e.g., '30s', '5m', '1h'). Defaults to 5m")
-	// cmd.Flags().Var(&opts.ArtifactType, "as", "Type of artifact to push (oci, imgpkg, educates). Defaults to oci")
+	cmd.Flags().StringArrayVarP(&opts.Tags, "tag", "", nil, "Additional tag applied to the pushed artifact (can be repeated)")
+	cmd.Flags().BoolVarP(&opts.FloatingTags, "floating-tags", "", false, "Also tag the artifact with the major.minor and major versions of its semantic version tags (e.g. '1.4' and '1' for '1.4.2')")
+	cmd.Flags().StringVarP(&opts.From, "from", "", "", "Repository of the same registry holding some of the pushed blobs (e.g. 'ghcr.io/my-user/my-app'), mounted instead of uploaded")
+	cmd.Flags().StringArrayVarP(&opts.Annotations, "annotation", "", nil, "Annotation added to the pushed manifests and index as 'key=value' (can be repeated). Overrides the annotation file and the standard annotations")
+	cmd.Flags().StringVarP(&opts.AnnotationFile, "annotation-file", "", "", "YAML or JSON file mapping annotation keys to values, added to the pushed manifests and index")
+	cmd.Flags().BoolVarP(&opts.StandardAnnotations, "standard-annotations", "", true, "Add the org.opencontainers.image created, source, revision and version annotations, read from the git repository of the folder when available")
+	cmd.Flags().BoolVarP(&opts.DryRun, "dry-run", "", false, "Package the folder and print the descriptors and the JSON of the configs, manifests and index that would be pushed, without contacting the registry")
+	cmd.Flags().StringVarP(&opts.OutputFormat, "output", "", "", "Print the push result (root digest, manifests and layers) in this format (json, yaml). Progress messages are then written to the standard error")
+	cmd.Flags().StringVarP(&opts.DigestFile, "digest-file", "", "", "File to write the immutable reference of the pushed artifact to ('repository@digest')")
+	cmd.Flags().VarP(&opts.ArtifactType, "as", "a", "Type of artifact to push (oci, imgpkg, educates). Defaults to oci")
+	cmd.Flags().BoolVarP(&opts.ArtifactManifest, "artifact-manifest", "", false, "Push OCI 1.1 artifact manifests (artifactType and empty config) instead of image manifests")
+	cmd.Flags().VarP(&opts.Compression, "compression", "", "Compression of the pushed layers (gzip, zstd, none). Defaults to gzip")
+	cmd.Flags().BoolVarP(&opts.Reproducible, "reproducible", "", false, "Push reproducible layers (sorted entries, normalized owners, timestamps from SOURCE_DATE_EPOCH or the Unix epoch), so the same content gives the same digests")
+	cmd.Flags().BoolVarP(&opts.CopyImages, "copy-images", "", false, "Copy the images referenced by the .imgpkg/images.yml lock of an imgpkg bundle into the bundle repository")
+	cmd.Flags().BoolVarP(&opts.FileLayers, "file-layers", "", false, "Push every file as its own uncompressed layer named by its org.opencontainers.image.title annotation, like 'oras push'")
+	cmd.Flags().StringVarP(&opts.IgnoreFile, "ignore-file", "", "", "Ignore file (gitignore syntax) to use instead of the .artifactignore file at the root of the folder")
+	cmd.Flags().StringArrayVarP(&opts.IncludePaths, "include", "", nil, "Only push the files matching this pattern, relative to the folder (e.g. 'workshop/**'). Can be repeated")
+	cmd.Flags().StringArrayVarP(&opts.ExcludePaths, "exclude", "", nil, "Do not push the files matching this pattern, relative to the folder (e.g. 'docs/**'). Can be repeated and takes precedence over --include")
+	cmd.Flags().VarP(&opts.Symlinks, "symlinks", "", "How symlinks are pushed: preserve (store them as symlinks), follow (store the content they point to) or error. Symlinks pointing outside of the folder are always refused. Defaults to follow")
+	cmd.Flags().StringArrayVarP(&opts.LayerPaths, "layer", "l", nil, "Sub-path of the folder to push as its own layer (can be repeated). The rest of the folder goes into a final layer")
 	cmd.Flags().StringVarP(&opts.Username, "username", "u", "", "Username for registry authentication (can also use ARTIFACT_CLI_USERNAME env var)")
 	cmd.Flags().StringVarP(&opts.Password, "password", "w", "", "Password or token for registry authentication (can also use ARTIFACT_CLI_PASSWORD env var)")
 	cmd.Flags().BoolVarP(&opts.Insecure, "insecure", "", false, "Allow insecure registry communication")
@@ -74,28 +165,101 @@ func runPush(opts PushCmdOpts) error {
 	repoRef := artifact.NewRepositoryRef(opts.ImageRef, opts.Username, opts.Password, opts.Insecure)
 	platforms := utils.SlicePlatforms(opts.Platforms)
 
+	folderPath, platformFolders, folderPlatforms, err := parseFolders(opts.Folders)
+	if err != nil {
+		return err
+	}
+	if len(platformFolders) > 0 {
+		if len(platforms) != 0 {
+			return fmt.Errorf("--platforms cannot be combined with per-platform folders, the platforms are taken from the folders")
+		}
+		if len(opts.LayerPaths) != 0 && folderPath == "" {
+			return fmt.Errorf("--layer requires a common folder when pushing per-platform folders")
+		}
+		platforms = folderPlatforms
+	}
+
+	for _, pattern := range append(append([]string{}, opts.IncludePaths...), opts.ExcludePaths...) {
+		if !doublestar.ValidatePattern(pattern) {
+			return fmt.Errorf("invalid include or exclude pattern: %s", pattern)
+		}
+	}
+
+	switch opts.OutputFormat {
+	case "", OutputFormatJSON, OutputFormatYAML:
+	default:
+		return fmt.Errorf("unsupported output format: %s (supported: json, yaml)", opts.OutputFormat)
+	}
+
+	// The standard output only holds the push result when one is requested, so that it can be parsed
+	var progress io.Writer = os.Stdout
+	if opts.OutputFormat != "" {
+		progress = os.Stderr
+		utils.SetVerboseOutput(os.Stderr)
+	}
+
+	annotations, err := artifact.ParseAnnotations(opts.Annotations, opts.AnnotationFile)
+	if err != nil {
+		return err
+	}
+
+	if opts.FileLayers && len(opts.LayerPaths) != 0 {
+		return fmt.Errorf("--layer cannot be combined with --file-layers, every file is already its own layer")
+	}
+
 	// Do some validation
-	// if opts.ArtifactType == ArtifactTypeImgpkg && len(platforms) != 0 {
-	// 	utils.VerbosePrintln("when pushing an Imgpkg artifact, platforms will be ignored")
-	// 	platforms = nil
-	// }
+	if opts.ArtifactType == artifact.ArtifactTypeImgpkg && len(platforms) != 0 {
+		utils.VerbosePrintln("when pushing an Imgpkg artifact, platforms will be ignored")
+		platforms = nil
+	}
+
+	if opts.ArtifactType != artifact.ArtifactTypeImgpkg {
+		if opts.CopyImages {
+			return fmt.Errorf("--copy-images is only supported for imgpkg bundles (--as imgpkg)")
+		}
+		if folderPath != "" && imgpkg.IsBundleFolder(folderPath) {
+			utils.VerbosePrintf("folder '%s' holds a %s directory, use --as imgpkg to push it as an imgpkg bundle\n", folderPath, imgpkg.BundleDir)
+		}
+	}
 
 	if err := utils.ValidatePlatforms(platforms); err != nil {
 		return err
 	}
+	platforms, err = utils.NormalizePlatforms(platforms)
+	if err != nil {
+		return err
+	}
 
-	var artifactInstance artifact.Artifact
+	artifactOpts := artifact.Options{
+		ArtifactManifest:    opts.ArtifactManifest,
+		Compression:         opts.Compression,
+		LayerPaths:          opts.LayerPaths,
+		CopyBundleImages:    opts.CopyImages,
+		Reproducible:        opts.Reproducible,
+		PlatformFolders:     platformFolders,
+		FileLayers:          opts.FileLayers,
+		IgnoreFile:          opts.IgnoreFile,
+		IncludePaths:        opts.IncludePaths,
+		ExcludePaths:        opts.ExcludePaths,
+		Symlinks:            opts.Symlinks,
+		Tags:                opts.Tags,
+		FloatingTags:        opts.FloatingTags,
+		MountFrom:           opts.From,
+		Annotations:         annotations,
+		StandardAnnotations: opts.StandardAnnotations,
+		Progress:            progress,
+		DryRun:              opts.DryRun,
+	}
+	if _, err := artifactOpts.PushTags(opts.ImageRef, nil); err != nil {
+		return err
+	}
 
-	// switch opts.ArtifactType {
-	// case ArtifactTypeOci:
-	artifactInstance = oci.NewOciImageArtifact(repoRef, platforms, "", opts.FolderPath)
-	// case ArtifactTypeImgpkg:
-	// 	artifact = imgpkg.NewImgpkgImageArtifact(repoRef, platforms, "", opts.FolderPath)
-	// case ArtifactTypeEducates:
-	// 	artifact = educates.NewEducatesImageArtifact(repoRef, platforms, "", opts.FolderPath)
-	// }
+	artifactInstance, err := formats.New(opts.ArtifactType, repoRef, platforms, "", folderPath, artifactOpts)
+	if err != nil {
+		return err
+	}
 
-	err = artifactInstance.Push(ctx)
+	result, err := artifactInstance.Push(ctx)
 	if err != nil {
 		// Check if the error was due to user cancellation
 		if utils.IsCancelledByUser(ctx) {
@@ -106,5 +270,73 @@ func runPush(opts PushCmdOpts) error {
 		return err
 	}
 
+	if opts.DigestFile != "" {
+		if result.DryRun {
+			fmt.Fprintf(progress, "Dry run: digest file %s not written\n", opts.DigestFile)
+		} else {
+			if err := os.WriteFile(opts.DigestFile, []byte(result.DigestReference+"\n"), 0644); err != nil {
+				return fmt.Errorf("failed to write digest file: %w", err)
+			}
+			utils.VerbosePrintf("Wrote %s to %s\n", result.DigestReference, opts.DigestFile)
+		}
+	}
+
+	switch opts.OutputFormat {
+	case OutputFormatJSON:
+		return outputJSON(result)
+	case OutputFormatYAML:
+		return outputYAML(result)
+	}
 	return nil
 }
+
+// parseFolders splits the --folder values into the common folder and the per-platform folders, keeping
+// the platforms in the order they were given. A value is a per-platform folder when it starts with a
+// platform followed by '=' (e.g. 'linux/amd64=./dist/amd64').
+func parseFolders(values []string) (string, map[string]string, []string, error) {
+	var folderPath string
+	var platforms []string
+	platformFolders := make(map[string]string)
+
+	for _, value := range values {
+		platform, path, isMapping := strings.Cut(value, "=")
+		if isMapping {
+			// Only values starting with a platform (e.g. 'linux/arm/v7=') are platform folders
+			_, err := utils.NewPlatform(platform)
+			isMapping = err == nil && !strings.HasPrefix(platform, ".") && !strings.HasPrefix(platform, "/")
+		}
+		if !isMapping {
+			if folderPath != "" {
+				return "", nil, nil, fmt.Errorf("only one folder without platform can be pushed, got '%s' and '%s'", folderPath, value)
+			}
+			folderPath = value
+			continue
+		}
+
+		if err := utils.ValidatePlatforms([]string{platform}); err != nil {
+			return "", nil, nil, err
+		}
+		// Platforms are normalized so that 'linux/aarch64' and 'linux/arm64' are the same platform
+		normalized, err := utils.NewPlatform(platform)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		platform = normalized.String()
+		if path == "" {
+			return "", nil, nil, fmt.Errorf("missing folder for platform %s", platform)
+		}
+		if _, ok := platformFolders[platform]; ok {
+			return "", nil, nil, fmt.Errorf("folder for platform %s provided more than once", platform)
+		}
+		platformFolders[platform] = path
+		platforms = append(platforms, platform)
+	}
+
+	if folderPath == "" && len(platforms) == 0 {
+		return "", nil, nil, fmt.Errorf("a folder to push is required")
+	}
+	if len(platforms) == 0 {
+		return folderPath, nil, nil, nil
+	}
+	return folderPath, platformFolders, platforms, nil
+}
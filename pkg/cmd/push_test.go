@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFolders(t *testing.T) {
+	tests := []struct {
+		name          string
+		values        []string
+		wantFolder    string
+		wantFolders   map[string]string
+		wantPlatforms []string
+		wantErr       bool
+	}{
+		{
+			name:       "single folder",
+			values:     []string{"./app"},
+			wantFolder: "./app",
+		},
+		{
+			name:       "folder holding an equal sign",
+			values:     []string{"./dist/key=value"},
+			wantFolder: "./dist/key=value",
+		},
+		{
+			name:       "absolute folder holding an equal sign",
+			values:     []string{"/tmp/key=value"},
+			wantFolder: "/tmp/key=value",
+		},
+		{
+			name:       "folder with a name that is not a platform",
+			values:     []string{"app=v1"},
+			wantFolder: "app=v1",
+		},
+		{
+			name:          "platform folders with a common folder",
+			values:        []string{"./common", "linux/amd64=./dist/amd64", "linux/arm64=./dist/arm64"},
+			wantFolder:    "./common",
+			wantFolders:   map[string]string{"linux/amd64": "./dist/amd64", "linux/arm64": "./dist/arm64"},
+			wantPlatforms: []string{"linux/amd64", "linux/arm64"},
+		},
+		{
+			name:          "platform folder with a variant",
+			values:        []string{"linux/arm/v7=./dist/armv7", "linux/arm/v6=./dist/armv6"},
+			wantFolders:   map[string]string{"linux/arm/v7": "./dist/armv7", "linux/arm/v6": "./dist/armv6"},
+			wantPlatforms: []string{"linux/arm/v7", "linux/arm/v6"},
+		},
+		{
+			name:          "normalized platform folder",
+			values:        []string{"linux/aarch64=./dist/arm64"},
+			wantFolders:   map[string]string{"linux/arm64": "./dist/arm64"},
+			wantPlatforms: []string{"linux/arm64"},
+		},
+		{
+			name:    "same platform twice",
+			values:  []string{"linux/arm64=./a", "linux/aarch64=./b"},
+			wantErr: true,
+		},
+		{
+			name:    "platform without folder",
+			values:  []string{"linux/amd64="},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported platform",
+			values:  []string{"plan9/mips=./dist"},
+			wantErr: true,
+		},
+		{
+			name:    "two folders without platform",
+			values:  []string{"./a", "./b"},
+			wantErr: true,
+		},
+		{
+			name:    "no folder",
+			values:  nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folder, folders, platforms, err := parseFolders(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFolders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if folder != tt.wantFolder {
+				t.Errorf("parseFolders() folder = %q, want %q", folder, tt.wantFolder)
+			}
+			if !reflect.DeepEqual(folders, tt.wantFolders) {
+				t.Errorf("parseFolders() platform folders = %v, want %v", folders, tt.wantFolders)
+			}
+			if !reflect.DeepEqual(platforms, tt.wantPlatforms) {
+				t.Errorf("parseFolders() platforms = %v, want %v", platforms, tt.wantPlatforms)
+			}
+		})
+	}
+}
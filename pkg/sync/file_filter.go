@@ -5,7 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/bmatcuk/doublestar/v4"
+	"educates-artifact-cli/pkg/utils"
 )
 
 type FileFilter struct {
@@ -14,9 +14,6 @@ type FileFilter struct {
 }
 
 func (d FileFilter) Apply(dirPath string) error {
-	includePaths := d.scopePatterns(append([]string{}, d.IncludePaths...), dirPath)
-	excludePaths := d.scopePatterns(append([]string{}, d.ExcludePaths...), dirPath)
-
 	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -25,27 +22,10 @@ func (d FileFilter) Apply(dirPath string) error {
 			return nil
 		}
 
-		var matched bool
-
-		if len(includePaths) == 0 {
-			matched = true
-		}
-
-		ok, err := d.matchAgainstPatterns(path, includePaths)
-		if err != nil {
-			return err
-		}
-		if ok {
-			matched = true
-		}
-
-		ok, err = d.matchAgainstPatterns(path, excludePaths)
+		matched, err := utils.SelectedByPatterns(dirPath, path, d.IncludePaths, d.ExcludePaths)
 		if err != nil {
 			return err
 		}
-		if ok {
-			matched = false
-		}
 
 		if !matched {
 			err := os.RemoveAll(path)
@@ -64,26 +44,6 @@ func (d FileFilter) Apply(dirPath string) error {
 	return err
 }
 
-func (d FileFilter) scopePatterns(patterns []string, dirPath string) []string {
-	for i, pattern := range patterns {
-		patterns[i] = filepath.Join(dirPath, pattern)
-	}
-	return patterns
-}
-
-func (d FileFilter) matchAgainstPatterns(path string, patterns []string) (bool, error) {
-	for _, pattern := range patterns {
-		ok, err := doublestar.PathMatch(pattern, path)
-		if err != nil {
-			return false, err
-		}
-		if ok {
-			return true, nil
-		}
-	}
-	return false, nil
-}
-
 func (d FileFilter) deleteEmptyDirs(dirPath string, topLevel bool) (bool, error) {
 	files, err := os.ReadDir(dirPath)
 	if err != nil {
@@ -3,7 +3,7 @@ package sync
 import (
 	"context"
 	"educates-artifact-cli/pkg/artifact"
-	"educates-artifact-cli/pkg/artifact/oci"
+	"educates-artifact-cli/pkg/artifact/formats"
 	"educates-artifact-cli/pkg/utils"
 	"fmt"
 	"os"
@@ -53,28 +53,24 @@ func processArtifact(ctx context.Context, artifactConfig SyncArtifact, destDir s
 		return fmt.Errorf("failed to create temp directory: %w", err)
 	}
 
-	// Determine artifact type and create appropriate artifact handler
-	var artifactHandler artifact.Artifact
-
-	// Try to determine artifact type by attempting different pull strategies
-	// This follows the same fallback logic as the pull command
 	platformStr := utils.GetOSPlatformStr()
 
 	// Create repository reference with credentials
 	repoRef := artifact.NewRepositoryRef(artifactConfig.Image.URL, artifactConfig.Image.Username, artifactConfig.Image.Password, artifactConfig.Image.Insecure)
 
-	// Try OCI format first
-	artifactHandler = oci.NewOciImageArtifact(repoRef, nil, platformStr, tempDir)
+	// Determine artifact type from the registry and create appropriate artifact handler
+	artifactHandler, artifactType, err := formats.Detect(ctx, repoRef, platformStr, tempDir, artifact.Options{
+		RelocateBundleImages: artifactConfig.RelocateImages,
+		LayerMediaTypes:      artifactConfig.LayerMediaTypes,
+		LayerIndexes:         artifactConfig.LayerIndexes,
+	})
+	if err != nil {
+		return err
+	}
+	utils.VerbosePrintf("Pulling artifact as %s\n", artifactType)
+
 	if err := artifactHandler.Pull(ctx); err != nil {
-		// // Try imgpkg format
-		// artifactHandler = imgpkg.NewImgpkgImageArtifact(repoRef, nil, platformStr, tempDir)
-		// if err := artifactHandler.Pull(ctx); err != nil {
-		// 	// Try educates format
-		// 	artifactHandler = educates.NewEducatesImageArtifact(repoRef, nil, platformStr, tempDir)
-		// 	if err := artifactHandler.Pull(ctx); err != nil {
-		return fmt.Errorf("failed to pull artifact with any supported format: %w", err)
-		// 	}
-		// }
+		return fmt.Errorf("failed to pull %s artifact: %w", artifactType, err)
 	}
 
 	// Apply include/exclude patterns and copy files to destination
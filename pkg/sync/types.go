@@ -15,6 +15,11 @@ type SyncArtifact struct {
 	Path         string        `yaml:"path" json:"path"`
 	IncludePaths []string      `json:"includePaths,omitempty" yaml:"includePaths,omitempty"`
 	ExcludePaths []string      `json:"excludePaths,omitempty" yaml:"excludePaths,omitempty"`
+	// RelocateImages rewrites the images lock of imgpkg bundles to reference the images copied with the bundle
+	RelocateImages bool `json:"relocateImages,omitempty" yaml:"relocateImages,omitempty"`
+	// LayerMediaTypes and LayerIndexes select the layers to extract, e.g. the content layer of a Helm chart
+	LayerMediaTypes []string `json:"layerMediaTypes,omitempty" yaml:"layerMediaTypes,omitempty"`
+	LayerIndexes    []int    `json:"layerIndexes,omitempty" yaml:"layerIndexes,omitempty"`
 }
 
 type ArtifactImage struct {
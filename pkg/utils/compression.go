@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the algorithm used to compress a layer tarball.
+// It implements the pflag.Value interface so it can be used directly as a command flag.
+type Compression string
+
+const (
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+	CompressionNone Compression = "none"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// String returns the string representation of the compression
+func (c *Compression) String() string {
+	return string(*c)
+}
+
+// Set parses and validates the compression from a flag value
+func (c *Compression) Set(value string) error {
+	switch Compression(strings.ToLower(strings.TrimSpace(value))) {
+	case CompressionGzip:
+		*c = CompressionGzip
+	case CompressionZstd:
+		*c = CompressionZstd
+	case CompressionNone:
+		*c = CompressionNone
+	default:
+		return fmt.Errorf("unsupported compression: %s (supported: gzip, zstd, none)", value)
+	}
+	return nil
+}
+
+// Type returns the type name shown in the command help
+func (c *Compression) Type() string {
+	return "compression"
+}
+
+// CompressionFromMediaType returns the compression declared by a layer media type suffix
+// (e.g. '+gzip', '+zstd', '.gzip'). It returns an empty compression when the media type
+// does not declare one.
+func CompressionFromMediaType(mediaType string) Compression {
+	switch {
+	case strings.HasSuffix(mediaType, "+gzip"), strings.HasSuffix(mediaType, ".gzip"):
+		return CompressionGzip
+	case strings.HasSuffix(mediaType, "+zstd"), strings.HasSuffix(mediaType, ".zstd"):
+		return CompressionZstd
+	case strings.HasSuffix(mediaType, ".tar"):
+		return CompressionNone
+	default:
+		return ""
+	}
+}
+
+// SniffCompression detects the compression of a stream from its magic bytes
+func SniffCompression(header []byte) Compression {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return CompressionGzip
+	case bytes.HasPrefix(header, zstdMagic):
+		return CompressionZstd
+	default:
+		return CompressionNone
+	}
+}
+
+// NewCompressedWriter wraps a writer so that everything written to it is compressed.
+// The returned writer must be closed to flush the compressed stream. The gzip header has no file name
+// nor modification time, so the same content always gives the same compressed stream.
+func NewCompressedWriter(w io.Writer, compression Compression) (io.WriteCloser, error) {
+	switch compression {
+	case CompressionGzip, "":
+		return gzip.NewWriter(w), nil
+	case CompressionZstd:
+		return zstd.NewWriter(w)
+	case CompressionNone:
+		return nopWriteCloser{w}, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+}
+
+// NewDecompressedReader wraps a reader so that its content is decompressed. When the compression
+// is empty, it is detected from the magic bytes at the beginning of the stream.
+func NewDecompressedReader(r io.Reader, compression Compression) (io.ReadCloser, error) {
+	if compression == "" {
+		bufferedReader := bufio.NewReader(r)
+		// Peek returns an error for streams shorter than the magic bytes, which are then uncompressed
+		header, _ := bufferedReader.Peek(len(zstdMagic))
+		compression = SniffCompression(header)
+		VerbosePrintf("Detected %s compression from layer content\n", compression)
+		r = bufferedReader
+	}
+
+	switch compression {
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	case CompressionNone:
+		return io.NopCloser(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+var testCompressions = []Compression{CompressionGzip, CompressionZstd, CompressionNone}
+
+// compressTestContent compresses the content with the given compression
+func compressTestContent(t *testing.T, content []byte, compression Compression) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewCompressedWriter(&buf, compression)
+	if err != nil {
+		t.Fatalf("NewCompressedWriter() error = %v", err)
+	}
+	if _, err := w.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	content := bytes.Repeat([]byte("artifact content\n"), 1000)
+
+	for _, compression := range testCompressions {
+		t.Run(string(compression), func(t *testing.T) {
+			compressed := compressTestContent(t, content, compression)
+			if compression != CompressionNone && len(compressed) >= len(content) {
+				t.Errorf("compressed size = %d, want less than %d", len(compressed), len(content))
+			}
+
+			// The compression is either given or detected from the magic bytes
+			for _, readCompression := range []Compression{compression, ""} {
+				r, err := NewDecompressedReader(bytes.NewReader(compressed), readCompression)
+				if err != nil {
+					t.Fatalf("NewDecompressedReader(%q) error = %v", readCompression, err)
+				}
+				got, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatalf("reading the decompressed stream with %q: %v", readCompression, err)
+				}
+				if err := r.Close(); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, content) {
+					t.Errorf("NewDecompressedReader(%q) read %d bytes, want the %d bytes written", readCompression, len(got), len(content))
+				}
+			}
+		})
+	}
+}
+
+func TestDecompressedReaderShortStream(t *testing.T) {
+	r, err := NewDecompressedReader(bytes.NewReader([]byte("ab")), "")
+	if err != nil {
+		t.Fatalf("NewDecompressedReader() error = %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "ab" {
+		t.Errorf("NewDecompressedReader() read %q, want %q", got, "ab")
+	}
+}
+
+func TestUnsupportedCompression(t *testing.T) {
+	if _, err := NewCompressedWriter(io.Discard, "lz4"); err == nil {
+		t.Error("NewCompressedWriter() error = nil, want an unsupported compression error")
+	}
+	if _, err := NewDecompressedReader(bytes.NewReader(nil), "lz4"); err == nil {
+		t.Error("NewDecompressedReader() error = nil, want an unsupported compression error")
+	}
+}
+
+func TestSniffCompression(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   Compression
+	}{
+		{name: "gzip", header: compressTestContent(t, []byte("content"), CompressionGzip), want: CompressionGzip},
+		{name: "zstd", header: compressTestContent(t, []byte("content"), CompressionZstd), want: CompressionZstd},
+		{name: "tarball", header: []byte("./\x00\x00\x00\x00"), want: CompressionNone},
+		{name: "truncated zstd magic", header: zstdMagic[:2], want: CompressionNone},
+		{name: "empty", header: nil, want: CompressionNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SniffCompression(tt.header); got != tt.want {
+				t.Errorf("SniffCompression() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressionFromMediaType(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		want      Compression
+	}{
+		{mediaType: "application/vnd.oci.image.layer.v1.tar+gzip", want: CompressionGzip},
+		{mediaType: "application/vnd.oci.image.layer.v1.tar+zstd", want: CompressionZstd},
+		{mediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip", want: CompressionGzip},
+		{mediaType: "application/vnd.oci.image.layer.v1.tar", want: CompressionNone},
+		{mediaType: "application/octet-stream", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mediaType, func(t *testing.T) {
+			if got := CompressionFromMediaType(tt.mediaType); got != tt.want {
+				t.Errorf("CompressionFromMediaType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// writeTestFolder writes the same files to a new folder, with the given modification time
+func writeTestFolder(t *testing.T, modTime time.Time) string {
+	t.Helper()
+	src := t.TempDir()
+	for name, content := range map[string]string{"a": "one", "sub/b": "two", "sub/deep/c": "three"} {
+		target := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := filepath.Walk(src, func(path string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(path, modTime, modTime)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
+
+func TestWriteTarballReproducible(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	folders := []string{
+		writeTestFolder(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+		writeTestFolder(t, time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)),
+	}
+
+	for _, compression := range testCompressions {
+		t.Run(string(compression), func(t *testing.T) {
+			opts, err := NewReproducibleTarOptions(compression)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var digests []digest.Digest
+			for _, folder := range folders {
+				entries, err := CollectTarEntries(folder, FolderFilter{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				var buf bytes.Buffer
+				if _, err := WriteTarball(&buf, entries, opts); err != nil {
+					t.Fatalf("WriteTarball() error = %v", err)
+				}
+				if got := SniffCompression(buf.Bytes()); got != compression {
+					t.Errorf("tarball compression = %s, want %s", got, compression)
+				}
+				digests = append(digests, digest.FromBytes(buf.Bytes()))
+			}
+
+			if digests[0] != digests[1] {
+				t.Errorf("digests of the same content = %s and %s, want the same", digests[0], digests[1])
+			}
+		})
+	}
+}
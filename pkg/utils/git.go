@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// GitInfo describes the commit checked out in the git repository holding a folder
+type GitInfo struct {
+	// Source is the web URL of the origin remote, without credentials. Empty when there is no such remote
+	Source string
+	// Revision is the hash of the checked out commit
+	Revision string
+	// Tag is a tag pointing to the checked out commit, if any
+	Tag string
+}
+
+// scpLikeURLPattern matches the scp-like syntax of git remotes, e.g. 'git@github.com:my-user/my-repo.git'
+var scpLikeURLPattern = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// ReadGitInfo returns the git information of the folder, and false when git is not installed or the folder
+// is not in a git repository
+func ReadGitInfo(dir string) (GitInfo, bool) {
+	revision, err := gitOutput(dir, "rev-parse", "HEAD")
+	if err != nil {
+		VerbosePrintf("No git information for %s: %v\n", dir, err)
+		return GitInfo{}, false
+	}
+
+	info := GitInfo{Revision: revision}
+	if remote, err := gitOutput(dir, "config", "--get", "remote.origin.url"); err == nil {
+		info.Source = SourceURL(remote)
+	}
+	if tag, err := gitOutput(dir, "describe", "--tags", "--exact-match", "HEAD"); err == nil {
+		info.Tag = tag
+	}
+	return info, true
+}
+
+// SourceURL converts a git remote URL to the web URL of the repository, dropping the credentials and the
+// .git suffix (e.g. 'git@github.com:my-user/my-repo.git' is 'https://github.com/my-user/my-repo').
+// It returns an empty string for local remotes.
+func SourceURL(remote string) string {
+	if !strings.Contains(remote, "://") {
+		match := scpLikeURLPattern.FindStringSubmatch(remote)
+		if match == nil {
+			return ""
+		}
+		remote = "ssh://" + match[1] + "/" + strings.TrimPrefix(match[2], "/")
+	}
+
+	parsed, err := url.Parse(remote)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	switch parsed.Scheme {
+	case "http", "https":
+	case "ssh", "git", "git+ssh":
+		parsed.Scheme = "https"
+		parsed.Host = parsed.Hostname()
+	default:
+		return ""
+	}
+	parsed.User = nil
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	parsed.Path = strings.TrimSuffix(strings.TrimSuffix(parsed.Path, "/"), ".git")
+	return parsed.String()
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// IgnoreFileName is the file listing the paths of a folder that are not pushed, using the gitignore syntax.
+// It is read at the root of the folder and in its nested directories.
+const IgnoreFileName = ".artifactignore"
+
+// FolderFilter selects the entries of a folder that are packaged, and how its symlinks are packaged.
+// The zero value honours the .artifactignore files of the folder and follows its symlinks.
+type FolderFilter struct {
+	// IgnoreFile is read instead of the .artifactignore file at the root of the folder.
+	// The .artifactignore files of the nested directories are still honoured
+	IgnoreFile string
+	// Include and Exclude are doublestar patterns selecting the files of the folder that are not ignored,
+	// with the semantics of the sync include and exclude paths (see SelectedByPatterns)
+	Include []string
+	Exclude []string
+	// Symlinks is the symlink policy, empty for SymlinksFollow
+	Symlinks SymlinkPolicy
+}
+
+func (f FolderFilter) hasPatterns() bool {
+	return len(f.Include) > 0 || len(f.Exclude) > 0
+}
+
+// ignoreRule is a pattern of an ignore file
+type ignoreRule struct {
+	// Directory of the ignore file, relative to the root of the folder. Empty for the root
+	base     string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ignoreMatcher holds the rules of the ignore files read so far. Rules are in the order they are read,
+// so the rules of a nested directory come after the rules of its parents, and take precedence.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// load reads the rules of an ignore file. A missing file is not an error unless required.
+func (m *ignoreMatcher) load(filePath string, base string, required bool) error {
+	file, err := os.Open(filePath)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read ignore file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text(), base); ok {
+			m.rules = append(m.rules, rule)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read ignore file %s: %w", filePath, err)
+	}
+	VerbosePrintf("Using ignore file %s\n", filePath)
+	return nil
+}
+
+// parseIgnoreRule parses a line of an ignore file, following the gitignore format
+func parseIgnoreRule(line string, base string) (ignoreRule, bool) {
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimSuffix(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	// A pattern with a slash at the beginning or in the middle is relative to the directory of the
+	// ignore file, otherwise it matches at any level below it
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// Braces have no special meaning in the gitignore format
+	line = strings.NewReplacer("{", "\\{", "}", "\\}").Replace(line)
+	rule.pattern = line
+	return rule, true
+}
+
+// ignored reports whether an entry of the folder is ignored. The last rule matching the entry decides.
+// name is relative to the root of the folder and uses forward slashes.
+func (m *ignoreMatcher) ignored(name string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		rel := name
+		if rule.base != "" {
+			if !strings.HasPrefix(name, rule.base+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(name, rule.base+"/")
+		}
+		if !rule.anchored {
+			rel = path.Base(rel)
+		}
+		if matched, _ := doublestar.Match(rule.pattern, rel); matched {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// newIgnoreMatcher reads the root ignore file of a folder
+func newIgnoreMatcher(srcPath string, filter FolderFilter) (*ignoreMatcher, error) {
+	matcher := &ignoreMatcher{}
+	if filter.IgnoreFile != "" {
+		return matcher, matcher.load(filter.IgnoreFile, "", true)
+	}
+	return matcher, matcher.load(filepath.Join(srcPath, IgnoreFileName), "", false)
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		entry string
+		isDir bool
+		want  bool
+	}{
+		{name: "pattern at any level", rules: []string{"*.log"}, entry: "a/b/debug.log", want: true},
+		{name: "pattern not matching", rules: []string{"*.log"}, entry: "debug.txt", want: false},
+		{name: "directory pattern on a directory", rules: []string{"build/"}, entry: "src/build", isDir: true, want: true},
+		{name: "directory pattern on a file", rules: []string{"build/"}, entry: "build", want: false},
+		{name: "anchored pattern at the root", rules: []string{"/todo.txt"}, entry: "todo.txt", want: true},
+		{name: "anchored pattern below the root", rules: []string{"/todo.txt"}, entry: "docs/todo.txt", want: false},
+		{name: "pattern with a slash is anchored", rules: []string{"docs/*.md"}, entry: "docs/a.md", want: true},
+		{name: "pattern with a slash in a sub-directory", rules: []string{"docs/*.md"}, entry: "src/docs/a.md", want: false},
+		{name: "double star", rules: []string{"**/tmp"}, entry: "a/b/tmp", isDir: true, want: true},
+		{name: "negation", rules: []string{"*.log", "!keep.log"}, entry: "keep.log", want: false},
+		{name: "last matching rule decides", rules: []string{"!keep.log", "*.log"}, entry: "keep.log", want: true},
+		{name: "comments and blank lines", rules: []string{"# *.log", "", "   "}, entry: "debug.log", want: false},
+		{name: "escaped hash", rules: []string{`\#notes`}, entry: "#notes", want: true},
+		{name: "escaped exclamation mark", rules: []string{`\!important`}, entry: "!important", want: true},
+		{name: "trailing spaces", rules: []string{"*.log   "}, entry: "debug.log", want: true},
+		{name: "braces are literal", rules: []string{"{a,b}.txt"}, entry: "a.txt", want: false},
+		{name: "literal braces", rules: []string{"{a,b}.txt"}, entry: "{a,b}.txt", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher := &ignoreMatcher{}
+			for _, line := range tt.rules {
+				if rule, ok := parseIgnoreRule(line, ""); ok {
+					matcher.rules = append(matcher.rules, rule)
+				}
+			}
+			if got := matcher.ignored(tt.entry, tt.isDir); got != tt.want {
+				t.Errorf("ignored(%q) = %v, want %v", tt.entry, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectTarEntriesIgnoreFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		filter FolderFilter
+		want   []string
+	}{
+		{
+			name: "root ignore file",
+			files: map[string]string{
+				IgnoreFileName: "*.log\nbuild/\n",
+				"a.txt":        "",
+				"debug.log":    "",
+				"build/out":    "",
+				"src/x.log":    "",
+			},
+			want: []string{".", IgnoreFileName, "a.txt", "src"},
+		},
+		{
+			name: "nested ignore file",
+			files: map[string]string{
+				IgnoreFileName:            "*.tmp\n",
+				"sub/" + IgnoreFileName:   "*.txt\n!keep.tmp\n",
+				"a.txt":                   "",
+				"a.tmp":                   "",
+				"sub/b.txt":               "",
+				"sub/keep.tmp":            "",
+				"sub/other.tmp":           "",
+				"other/c.txt":             "",
+				"other/" + IgnoreFileName: "/c.txt\n",
+				"other/deep/c.txt":        "",
+			},
+			want: []string{".", IgnoreFileName, "a.txt", "other", "other/" + IgnoreFileName, "other/deep", "other/deep/c.txt", "sub", "sub/" + IgnoreFileName, "sub/keep.tmp"},
+		},
+		{
+			name: "ignore file given instead of the root one",
+			files: map[string]string{
+				IgnoreFileName: "*.txt\n",
+				"a.txt":        "",
+				"b.md":         "",
+			},
+			filter: FolderFilter{IgnoreFile: "custom-ignore"},
+			want:   []string{".", IgnoreFileName, "a.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := t.TempDir()
+			for name, content := range tt.files {
+				target := filepath.Join(src, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.filter.IgnoreFile != "" {
+				tt.filter.IgnoreFile = filepath.Join(t.TempDir(), tt.filter.IgnoreFile)
+				if err := os.WriteFile(tt.filter.IgnoreFile, []byte("*.md\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			entries, err := CollectTarEntries(src, tt.filter)
+			if err != nil {
+				t.Fatalf("CollectTarEntries() error = %v", err)
+			}
+			var got []string
+			for _, entry := range entries {
+				got = append(got, entry.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("CollectTarEntries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"path/filepath"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// SelectedByPatterns reports whether a file is selected by doublestar include and exclude patterns, relative
+// to the root folder holding it ('/workshop/**' and 'workshop/**' are the same pattern). Without include
+// pattern every file is included, and exclude patterns take precedence over include patterns.
+func SelectedByPatterns(root string, path string, includePatterns []string, excludePatterns []string) (bool, error) {
+	selected := len(includePatterns) == 0
+
+	included, err := matchPatterns(root, path, includePatterns)
+	if err != nil {
+		return false, err
+	}
+	if included {
+		selected = true
+	}
+
+	excluded, err := matchPatterns(root, path, excludePatterns)
+	if err != nil {
+		return false, err
+	}
+	if excluded {
+		selected = false
+	}
+	return selected, nil
+}
+
+func matchPatterns(root string, path string, patterns []string) (bool, error) {
+	for _, pattern := range patterns {
+		ok, err := doublestar.PathMatch(filepath.Join(root, pattern), path)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Platform is the OS and architecture an artifact is pushed for, with an optional variant and OS version.
+// Its string format is 'os[(osversion)]/arch[/variant]', e.g. 'linux/arm/v7' or 'windows(10.0.17763)/amd64'.
+// Platforms are always normalized, so that 'linux/aarch64' and 'linux/arm64/v8' are both 'linux/arm64'.
+type Platform struct {
+	OS           string
+	Architecture string
+	Variant      string
+	OSVersion    string
+}
+
+// DefaultSupportedPlatforms are the platforms accepted when no supported platforms are configured
+var DefaultSupportedPlatforms = []string{
+	"linux/amd64",
+	"linux/arm64",
+	"linux/arm/v7",
+	"linux/arm/v6",
+	"linux/386",
+	"linux/ppc64le",
+	"linux/s390x",
+	"linux/riscv64",
+	"darwin/amd64",
+	"darwin/arm64",
+	"windows/amd64",
+	"windows/arm64",
+}
+
+// SupportedPlatformsEnv is the environment variable used to configure the supported platforms
+const SupportedPlatformsEnv = "ARTIFACT_CLI_SUPPORTED_PLATFORMS"
+
+var supportedPlatforms = mustParsePlatforms(DefaultSupportedPlatforms)
+
+// SetSupportedPlatforms replaces the platforms accepted by ValidatePlatforms. A supported platform
+// without variant accepts every variant of its architecture.
+func SetSupportedPlatforms(platformStrs []string) error {
+	platforms := make([]Platform, 0, len(platformStrs))
+	for _, platformStr := range platformStrs {
+		platform, err := NewPlatform(platformStr)
+		if err != nil {
+			return fmt.Errorf("invalid supported platform: %w", err)
+		}
+		// Normalize defaults 'arm' to v7, but without variant every variant is supported
+		if parts := strings.Split(strings.TrimSpace(platformStr), "/"); len(parts) == 2 && strings.EqualFold(parts[1], "arm") {
+			platform.Variant = ""
+		}
+		platforms = append(platforms, platform)
+	}
+	if len(platforms) == 0 {
+		return fmt.Errorf("the list of supported platforms cannot be empty")
+	}
+	supportedPlatforms = platforms
+	return nil
+}
+
+// SupportedPlatformsFromEnv returns the supported platforms configured in the environment, if any
+func SupportedPlatformsFromEnv() []string {
+	return SlicePlatforms(os.Getenv(SupportedPlatformsEnv))
+}
+
+// SupportedPlatforms returns the platforms accepted by ValidatePlatforms
+func SupportedPlatforms() []Platform {
+	return append([]Platform(nil), supportedPlatforms...)
+}
+
+// NewPlatform parses and normalizes a platform string
+func NewPlatform(platformStr string) (Platform, error) {
+	parts := strings.Split(strings.TrimSpace(platformStr), "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Platform{}, fmt.Errorf("invalid platform format: %s (expected format: os/arch[/variant])", platformStr)
+	}
+
+	var platform Platform
+	platform.OS = parts[0]
+	if open := strings.Index(parts[0], "("); open != -1 {
+		if !strings.HasSuffix(parts[0], ")") {
+			return Platform{}, fmt.Errorf("invalid platform format: %s (expected format: os(osversion)/arch)", platformStr)
+		}
+		platform.OS = parts[0][:open]
+		platform.OSVersion = parts[0][open+1 : len(parts[0])-1]
+	}
+	platform.Architecture = parts[1]
+	if len(parts) == 3 {
+		platform.Variant = parts[2]
+	}
+
+	for _, part := range []string{platform.OS, platform.Architecture} {
+		if part == "" || strings.ContainsAny(part, " ()") {
+			return Platform{}, fmt.Errorf("invalid platform format: %s (expected format: os/arch[/variant])", platformStr)
+		}
+	}
+	if len(parts) == 3 && platform.Variant == "" {
+		return Platform{}, fmt.Errorf("invalid platform format: %s (empty variant)", platformStr)
+	}
+
+	return platform.Normalize(), nil
+}
+
+// NormalizePlatforms normalizes a list of platform strings, removing the duplicates
+func NormalizePlatforms(platformStrs []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, platformStr := range platformStrs {
+		platform, err := NewPlatform(platformStr)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[platform.String()] {
+			seen[platform.String()] = true
+			normalized = append(normalized, platform.String())
+		}
+	}
+	return normalized, nil
+}
+
+// PlatformFromOCI converts and normalizes the platform of an OCI descriptor
+func PlatformFromOCI(platform ocispec.Platform) Platform {
+	return Platform{
+		OS:           platform.OS,
+		Architecture: platform.Architecture,
+		Variant:      platform.Variant,
+		OSVersion:    platform.OSVersion,
+	}.Normalize()
+}
+
+// HostPlatform returns the platform of the current system
+func HostPlatform() Platform {
+	return Platform{OS: runtime.GOOS, Architecture: runtime.GOARCH}.Normalize()
+}
+
+// Normalize returns the canonical form of the platform, using the GOOS/GOARCH names and dropping
+// the variants implied by the architecture (e.g. 'arm64/v8')
+func (p Platform) Normalize() Platform {
+	p.OS = strings.ToLower(p.OS)
+	if p.OS == "macos" {
+		p.OS = "darwin"
+	}
+
+	p.Architecture = strings.ToLower(p.Architecture)
+	p.Variant = strings.ToLower(p.Variant)
+	if p.Variant != "" && !strings.HasPrefix(p.Variant, "v") {
+		p.Variant = "v" + p.Variant
+	}
+
+	switch p.Architecture {
+	case "i386", "i686", "x86":
+		p.Architecture = "386"
+		p.Variant = ""
+	case "x86_64", "x86-64", "amd64":
+		p.Architecture = "amd64"
+		if p.Variant == "v1" {
+			p.Variant = ""
+		}
+	case "aarch64", "arm64":
+		p.Architecture = "arm64"
+		if p.Variant == "v8" {
+			p.Variant = ""
+		}
+	case "armhf":
+		p.Architecture = "arm"
+		p.Variant = "v7"
+	case "armel":
+		p.Architecture = "arm"
+		p.Variant = "v6"
+	case "arm":
+		if p.Variant == "" {
+			p.Variant = "v7"
+		}
+	}
+	return p
+}
+
+// String returns the platform in the 'os[(osversion)]/arch[/variant]' format
+func (p Platform) String() string {
+	platformStr := p.OS
+	if p.OSVersion != "" {
+		platformStr += "(" + p.OSVersion + ")"
+	}
+	platformStr += "/" + p.Architecture
+	if p.Variant != "" {
+		platformStr += "/" + p.Variant
+	}
+	return platformStr
+}
+
+// OCI returns the platform as used in OCI descriptors
+func (p Platform) OCI() ocispec.Platform {
+	return ocispec.Platform{
+		OS:           p.OS,
+		Architecture: p.Architecture,
+		Variant:      p.Variant,
+		OSVersion:    p.OSVersion,
+	}
+}
+
+// compatibility returns how well content built for the candidate platform runs on p, lower is better.
+// It returns -1 when the candidate cannot run on p.
+func (p Platform) compatibility(candidate Platform) int {
+	if p.OS != candidate.OS {
+		return -1
+	}
+
+	rank := -1
+	for i, compatible := range p.compatiblePlatforms() {
+		if compatible.Architecture == candidate.Architecture && compatible.Variant == candidate.Variant {
+			rank = i
+			break
+		}
+	}
+	if rank == -1 {
+		// A platform without variant (e.g. the host platform) accepts any variant of its architecture, last
+		if p.Variant != "" || p.Architecture != candidate.Architecture {
+			return -1
+		}
+		rank = len(p.compatiblePlatforms())
+	}
+
+	// For the same architecture, prefer the manifest of the requested OS version
+	rank *= 2
+	if p.OSVersion != "" && candidate.OSVersion != p.OSVersion {
+		rank++
+	}
+	return rank
+}
+
+// compatiblePlatforms lists the architectures and variants able to run on p, from the best to the worst match
+func (p Platform) compatiblePlatforms() []Platform {
+	compatible := []Platform{{Architecture: p.Architecture, Variant: p.Variant}}
+	switch p.Architecture {
+	case "amd64":
+		// amd64 micro-architecture levels (v2, v3, v4) run the lower levels
+		for level := variantLevel(p.Variant) - 1; level >= 2; level-- {
+			compatible = append(compatible, Platform{Architecture: "amd64", Variant: fmt.Sprintf("v%d", level)})
+		}
+		if p.Variant != "" {
+			compatible = append(compatible, Platform{Architecture: "amd64"})
+		}
+	case "arm64":
+		// arm64 runs 32-bit arm content
+		for level := 8; level >= 5; level-- {
+			compatible = append(compatible, Platform{Architecture: "arm", Variant: fmt.Sprintf("v%d", level)})
+		}
+	case "arm":
+		for level := variantLevel(p.Variant) - 1; level >= 5; level-- {
+			compatible = append(compatible, Platform{Architecture: "arm", Variant: fmt.Sprintf("v%d", level)})
+		}
+	default:
+		if p.Variant != "" {
+			compatible = append(compatible, Platform{Architecture: p.Architecture})
+		}
+	}
+	return compatible
+}
+
+func variantLevel(variant string) int {
+	var level int
+	if _, err := fmt.Sscanf(variant, "v%d", &level); err != nil {
+		return 0
+	}
+	return level
+}
+
+// MatchPlatform returns the index of the candidate platform that best matches the target platform,
+// or -1 when none of them can run on it. An exact match is preferred, then the closest compatible
+// variant (e.g. 'linux/arm/v6' content for a 'linux/arm/v7' target).
+func MatchPlatform(target Platform, candidates []Platform) int {
+	best, bestRank := -1, -1
+	for i, candidate := range candidates {
+		rank := target.compatibility(candidate.Normalize())
+		if rank == -1 {
+			continue
+		}
+		if best == -1 || rank < bestRank {
+			best, bestRank = i, rank
+		}
+	}
+	return best
+}
+
+func mustParsePlatforms(platformStrs []string) []Platform {
+	platforms := make([]Platform, 0, len(platformStrs))
+	for _, platformStr := range platformStrs {
+		platform, err := NewPlatform(platformStr)
+		if err != nil {
+			panic(err)
+		}
+		platforms = append(platforms, platform)
+	}
+	return platforms
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewPlatform(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "linux/amd64", want: "linux/amd64"},
+		{input: "linux/aarch64", want: "linux/arm64"},
+		{input: "linux/arm64/v8", want: "linux/arm64"},
+		{input: "linux/arm", want: "linux/arm/v7"},
+		{input: "linux/arm/6", want: "linux/arm/v6"},
+		{input: "linux/armhf", want: "linux/arm/v7"},
+		{input: "macos/arm64", want: "darwin/arm64"},
+		{input: "windows(10.0.17763)/amd64", want: "windows(10.0.17763)/amd64"},
+		{input: "linux", wantErr: true},
+		{input: "linux/", wantErr: true},
+		{input: "linux/arm/", wantErr: true},
+		{input: "linux/arm/v7/extra", wantErr: true},
+		{input: "windows(10.0/amd64", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			platform, err := NewPlatform(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewPlatform() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && platform.String() != tt.want {
+				t.Errorf("NewPlatform() = %s, want %s", platform, tt.want)
+			}
+		})
+	}
+}
+
+func TestSupportedPlatformsVariants(t *testing.T) {
+	defer func() { supportedPlatforms = mustParsePlatforms(DefaultSupportedPlatforms) }()
+
+	tests := []struct {
+		supported []string
+		platform  string
+		wantErr   bool
+	}{
+		{supported: []string{"linux/arm"}, platform: "linux/arm/v6"},
+		{supported: []string{"linux/arm"}, platform: "linux/arm/v7"},
+		{supported: []string{"linux/arm"}, platform: "linux/arm"},
+		{supported: []string{"linux/arm/v7"}, platform: "linux/arm/v6", wantErr: true},
+		{supported: []string{"linux/armhf"}, platform: "linux/arm/v6", wantErr: true},
+		{supported: []string{"linux/amd64"}, platform: "linux/arm64", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.supported[0]+" accepts "+tt.platform, func(t *testing.T) {
+			if err := SetSupportedPlatforms(tt.supported); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidatePlatforms([]string{tt.platform})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePlatforms() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchPlatform(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		candidates []string
+		want       int
+	}{
+		{name: "exact match", target: "linux/amd64", candidates: []string{"linux/arm64", "linux/amd64"}, want: 1},
+		{name: "different OS", target: "windows/amd64", candidates: []string{"linux/amd64"}, want: -1},
+		{name: "no candidates", target: "linux/amd64", want: -1},
+		{name: "arm v7 prefers v7", target: "linux/arm/v7", candidates: []string{"linux/arm/v6", "linux/arm/v7"}, want: 1},
+		{name: "arm v7 runs v6", target: "linux/arm/v7", candidates: []string{"linux/amd64", "linux/arm/v6"}, want: 1},
+		{name: "arm v6 does not run v7", target: "linux/arm/v6", candidates: []string{"linux/arm/v7"}, want: -1},
+		{name: "arm v6 prefers v6", target: "linux/arm/v6", candidates: []string{"linux/arm/v7", "linux/arm/v6", "linux/arm/v5"}, want: 1},
+		{name: "arm defaults to v7", target: "linux/arm", candidates: []string{"linux/arm/v6", "linux/arm/v7"}, want: 1},
+		{name: "arm64 prefers arm64", target: "linux/arm64", candidates: []string{"linux/arm/v7", "linux/arm64"}, want: 1},
+		{name: "arm64 runs the highest arm variant", target: "linux/arm64", candidates: []string{"linux/arm/v6", "linux/arm/v7"}, want: 1},
+		{name: "normalized candidates", target: "linux/arm64", candidates: []string{"linux/amd64", "linux/aarch64"}, want: 1},
+		{name: "amd64 level runs lower levels", target: "linux/amd64/v3", candidates: []string{"linux/amd64/v4", "linux/amd64/v2"}, want: 1},
+		{name: "amd64 level prefers the closest level", target: "linux/amd64/v3", candidates: []string{"linux/amd64", "linux/amd64/v2"}, want: 1},
+		{name: "amd64 prefers no level", target: "linux/amd64", candidates: []string{"linux/amd64/v3", "linux/amd64"}, want: 1},
+		{name: "amd64 falls back to a level", target: "linux/amd64", candidates: []string{"linux/amd64/v3"}, want: 0},
+		{name: "windows OS version", target: "windows(10.0.20348)/amd64", candidates: []string{"windows(10.0.17763)/amd64", "windows(10.0.20348)/amd64"}, want: 1},
+		{name: "windows OS version not available", target: "windows(10.0.26100)/amd64", candidates: []string{"windows(10.0.17763)/amd64"}, want: 0},
+		{name: "windows without OS version", target: "windows/amd64", candidates: []string{"windows(10.0.17763)/amd64", "windows(10.0.20348)/amd64"}, want: 0},
+		{name: "windows OS version of another architecture", target: "windows(10.0.20348)/amd64", candidates: []string{"windows(10.0.20348)/arm64", "windows(10.0.17763)/amd64"}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := NewPlatform(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var candidates []Platform
+			for _, candidate := range tt.candidates {
+				// Candidates come from the descriptors of an index, which may not be normalized
+				osName, arch, _ := strings.Cut(candidate, "/")
+				arch, variant, _ := strings.Cut(arch, "/")
+				platform := Platform{OS: osName, Architecture: arch, Variant: variant}
+				if open := strings.Index(osName, "("); open != -1 {
+					platform.OS, platform.OSVersion = osName[:open], strings.TrimSuffix(osName[open+1:], ")")
+				}
+				candidates = append(candidates, platform)
+			}
+			if got := MatchPlatform(target, candidates); got != tt.want {
+				t.Errorf("MatchPlatform(%s, %v) = %d, want %d", tt.target, tt.candidates, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SymlinkPolicy is how the symlinks of a pushed folder are packaged.
+// It implements the pflag.Value interface so it can be used directly as a command flag.
+type SymlinkPolicy string
+
+const (
+	// SymlinksPreserve stores the symlinks as symlink entries, recreated on extraction. The CLIs that only
+	// extract regular files and directories cannot pull such artifacts
+	SymlinksPreserve SymlinkPolicy = "preserve"
+	// SymlinksFollow stores the content of the files and directories the symlinks point to. It is the default
+	SymlinksFollow SymlinkPolicy = "follow"
+	// SymlinksError refuses to package a folder holding symlinks
+	SymlinksError SymlinkPolicy = "error"
+)
+
+// maxSymlinks is the number of symlinks followed when resolving a path, after which it is considered a loop
+const maxSymlinks = 255
+
+// String returns the string representation of the policy
+func (p *SymlinkPolicy) String() string {
+	return string(*p)
+}
+
+// Set parses and validates the policy from a flag value
+func (p *SymlinkPolicy) Set(value string) error {
+	switch SymlinkPolicy(strings.ToLower(strings.TrimSpace(value))) {
+	case SymlinksPreserve:
+		*p = SymlinksPreserve
+	case SymlinksFollow:
+		*p = SymlinksFollow
+	case SymlinksError:
+		*p = SymlinksError
+	default:
+		return fmt.Errorf("unsupported symlink policy: %s (supported: preserve, follow, error)", value)
+	}
+	return nil
+}
+
+// Type returns the type name shown in the command help
+func (p *SymlinkPolicy) Type() string {
+	return "policy"
+}
+
+// checkSymlinkTarget makes sure that a symlink entry of an archive points within the archive, and within
+// the extracted sub-path if any. Absolute targets are refused, as they point outside of the archive.
+func checkSymlinkTarget(name string, linkName string, subPath string) error {
+	if path.IsAbs(linkName) || filepath.IsAbs(linkName) {
+		return fmt.Errorf("symlink %s points to the absolute path %s", name, linkName)
+	}
+	target := path.Join(path.Dir(name), filepath.ToSlash(linkName))
+	if target == ".." || strings.HasPrefix(target, "../") {
+		return fmt.Errorf("symlink %s to %s escapes the root of the folder", name, linkName)
+	}
+	if _, ok := relativeToSubPath(target, subPath); !ok {
+		return fmt.Errorf("symlink %s to %s points outside of the extracted path %s", name, linkName, subPath)
+	}
+	return nil
+}
+
+// isWithin reports whether a cleaned path is the root or one of its descendants
+func isWithin(root string, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveInDest returns the path on disk of a path relative to the destination, following the symlinks
+// of its parent directories (e.g. an image layer writing to 'lib/' when a previous layer made it a link to
+// 'usr/lib'). Symlinks are resolved as if the destination was the root of the filesystem, so the returned
+// path never escapes it. The last element of the path is not followed.
+func resolveInDest(dest string, rel string) (string, error) {
+	resolved := ""
+	remaining := rel
+	links := 0
+	for remaining != "" {
+		var part string
+		part, remaining, _ = strings.Cut(remaining, "/")
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			// '..' never goes above the destination
+			resolved = path.Dir(resolved)
+			if resolved == "." {
+				resolved = ""
+			}
+			continue
+		}
+
+		next := path.Join(resolved, part)
+		if remaining == "" {
+			resolved = next
+			continue
+		}
+
+		info, err := os.Lstat(filepath.Join(dest, filepath.FromSlash(next)))
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		links++
+		if links > maxSymlinks {
+			return "", fmt.Errorf("too many levels of symbolic links in %s", rel)
+		}
+		linkName, err := os.Readlink(filepath.Join(dest, filepath.FromSlash(next)))
+		if err != nil {
+			return "", err
+		}
+		linkName = filepath.ToSlash(linkName)
+		if path.IsAbs(linkName) {
+			resolved = ""
+		}
+		remaining = linkName + "/" + remaining
+	}
+	return filepath.Join(dest, filepath.FromSlash(resolved)), nil
+}
+
+// verifySymlinks makes sure that none of the symlinks created by an extraction resolves outside of the
+// destination once combined with the other symlinks (e.g. 'a -> b/../..' with 'b -> ..'). Offending
+// symlinks are removed, and reported as an error unless skip is true. The other content of the
+// destination, including the symlinks it already held, is left untouched.
+func verifySymlinks(dest string, links []string, skip bool) error {
+	root, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		info, err := os.Lstat(link)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			// Replaced or removed by a later entry
+			continue
+		}
+		resolved, err := filepath.EvalSymlinks(link)
+		if err != nil {
+			// Dangling symlinks were checked when extracted and point within the destination
+			continue
+		}
+		if isWithin(root, resolved) {
+			continue
+		}
+		if err := os.Remove(link); err != nil {
+			return err
+		}
+		if skip {
+			VerbosePrintf("Removed symlink %s, which resolves outside of the destination\n", link)
+			continue
+		}
+		return fmt.Errorf("symlink %s resolves outside of the destination folder", link)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifySymlinks(t *testing.T) {
+	tests := []struct {
+		name string
+		// Symlinks of the destination, name to target, created by the extraction when created is true
+		links map[string]string
+		// Names of the links created by the extraction
+		created []string
+		skip    bool
+		wantErr bool
+		// Links expected to be removed
+		removed []string
+	}{
+		{
+			name:    "link within the destination",
+			links:   map[string]string{"a": "b"},
+			created: []string{"a"},
+		},
+		{
+			name:    "chained links escaping the destination",
+			links:   map[string]string{"b": "..", "a": "b/.."},
+			created: []string{"a", "b"},
+			wantErr: true,
+			removed: []string{"a"},
+		},
+		{
+			name:    "escaping link skipped",
+			links:   map[string]string{"a": "../.."},
+			created: []string{"a"},
+			skip:    true,
+			removed: []string{"a"},
+		},
+		{
+			name:    "existing link of the user kept",
+			links:   map[string]string{"user-link": "/etc", "a": "b"},
+			created: []string{"a"},
+		},
+		{
+			name:    "created link through a link of the user",
+			links:   map[string]string{"user-link": "/etc", "a": "user-link"},
+			created: []string{"a"},
+			wantErr: true,
+			removed: []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			for name, target := range tt.links {
+				if err := os.Symlink(target, filepath.Join(dest, name)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var created []string
+			for _, name := range tt.created {
+				created = append(created, filepath.Join(dest, name))
+			}
+
+			err := verifySymlinks(dest, created, tt.skip)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifySymlinks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			removed := make(map[string]bool)
+			for _, name := range tt.removed {
+				removed[name] = true
+			}
+			for name := range tt.links {
+				_, err := os.Lstat(filepath.Join(dest, name))
+				if exists := err == nil; exists == removed[name] {
+					t.Errorf("link %s exists = %v, want %v", name, exists, !removed[name])
+				}
+			}
+		})
+	}
+}
+
+func TestExtractTarballKeepsExistingSymlinks(t *testing.T) {
+	dest := t.TempDir()
+	if err := os.Symlink("/etc", filepath.Join(dest, "user-link")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	entries := []*tar.Header{
+		{Name: "docs/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "docs/latest", Typeflag: tar.TypeSymlink, Linkname: "v1", Mode: 0777},
+	}
+	for _, header := range entries {
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ExtractTarball(&buf, dest, ExtractOptions{Compression: CompressionNone}); err != nil {
+		t.Fatalf("ExtractTarball() error = %v", err)
+	}
+	if target, err := os.Readlink(filepath.Join(dest, "user-link")); err != nil || target != "/etc" {
+		t.Errorf("existing link = %q, %v, want /etc", target, err)
+	}
+	if target, err := os.Readlink(filepath.Join(dest, "docs", "latest")); err != nil || target != "v1" {
+		t.Errorf("extracted link = %q, %v, want v1", target, err)
+	}
+}
+
+func TestCheckSymlinkTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		linkName string
+		subPath  string
+		wantErr  bool
+	}{
+		{name: "a", linkName: "b"},
+		{name: "dir/a", linkName: "../b"},
+		{name: "a", linkName: "../b", wantErr: true},
+		{name: "dir/a", linkName: "../../b", wantErr: true},
+		{name: "a", linkName: "/etc/passwd", wantErr: true},
+		{name: "sub/a", linkName: "b", subPath: "sub"},
+		{name: "sub/a", linkName: "../b", subPath: "sub", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"->"+tt.linkName, func(t *testing.T) {
+			err := checkSymlinkTarget(tt.name, tt.linkName, tt.subPath)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkSymlinkTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,102 +2,494 @@ package utils
 
 import (
 	"archive/tar"
-	"bytes"
-	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
 )
 
-// CreateTarGz archives a source folder into a gzipped tarball in memory.
-func CreateTarGz(srcPath string) ([]byte, error) {
-	var buf bytes.Buffer
-	gzipWriter := gzip.NewWriter(&buf)
-	defer gzipWriter.Close()
-	tarWriter := tar.NewWriter(gzipWriter)
-	defer tarWriter.Close()
+// TarEntry is a file or directory of a folder to be added to a tarball
+type TarEntry struct {
+	// Name of the entry in the archive, relative to the archived folder and using forward slashes
+	Name string
+	// Path of the content on disk. For followed symlinks this is the path of the target
+	Path string
+	// Info of the content on disk. For followed symlinks this is the info of the target
+	Info os.FileInfo
+	// Link is the target of a preserved symlink, empty for other entries
+	Link string
+}
+
+// TarOptions tunes how a tarball is written. The zero value writes a gzipped tarball keeping the
+// metadata of the files on disk.
+type TarOptions struct {
+	// Compression of the tarball. Defaults to gzip when empty
+	Compression Compression
+	// Reproducible writes the same tarball for the same content: entries are sorted by name, owners
+	// are normalized to root, and every entry uses ModTime instead of the time of the file on disk
+	Reproducible bool
+	// ModTime of the entries of reproducible tarballs
+	ModTime time.Time
+	// FileDigests records the digests of the regular files written, when set, so that the tree digest
+	// of the folder does not read them again
+	FileDigests FileDigests
+}
+
+// FileDigests are the digests of regular files, by their path on disk
+type FileDigests map[string]digest.Digest
+
+// NewReproducibleTarOptions returns the options of a reproducible tarball. Entries use the time set in
+// the SOURCE_DATE_EPOCH environment variable, or the Unix epoch when it is not set.
+func NewReproducibleTarOptions(compression Compression) (TarOptions, error) {
+	modTime := time.Unix(0, 0).UTC()
+	sourceDate, err := SourceDateEpoch()
+	if err != nil {
+		return TarOptions{}, err
+	}
+	if sourceDate != nil {
+		modTime = *sourceDate
+	}
+	return TarOptions{Compression: compression, Reproducible: true, ModTime: modTime}, nil
+}
+
+// SourceDateEpoch returns the time set in the SOURCE_DATE_EPOCH environment variable
+// (https://reproducible-builds.org/specs/source-date-epoch/), nil when it is not set.
+func SourceDateEpoch() (*time.Time, error) {
+	value := strings.TrimSpace(os.Getenv("SOURCE_DATE_EPOCH"))
+	if value == "" {
+		return nil, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SOURCE_DATE_EPOCH %s: %w", value, err)
+	}
+	sourceDate := time.Unix(seconds, 0).UTC()
+	return &sourceDate, nil
+}
+
+// CollectTarEntries walks a source folder and returns the entries to archive, in walk order.
+// Entries ignored by the .artifactignore files of the folder are left out, and symlinks are handled
+// according to the symlink policy, see FolderFilter.
+func CollectTarEntries(srcPath string, filter FolderFilter) ([]TarEntry, error) {
+	ignore, err := newIgnoreMatcher(srcPath, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Symlinks are checked against the real path of the folder
+	root, err := filepath.Abs(srcPath)
+	if err != nil {
+		return nil, err
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	walker := &folderWalker{srcPath: srcPath, root: root, filter: filter, ignore: ignore}
+	if err := walker.walk(srcPath, ".", []string{root}); err != nil {
+		return nil, err
+	}
+	entries := walker.entries
 
-	err := filepath.Walk(srcPath, func(path string, info os.FileInfo, err error) error {
+	if filter.hasPatterns() {
+		// Like the sync filters, directories left without files are not packaged
+		entries = withoutEmptyDirs(entries)
+		if len(entries) <= 1 {
+			return nil, fmt.Errorf("no file of folder '%s' matches the include and exclude patterns", srcPath)
+		}
+	}
+
+	return entries, nil
+}
+
+// folderWalker collects the entries of a folder, walking into the directories that symlinks point to
+// when they are followed
+type folderWalker struct {
+	srcPath string
+	// Real path of the folder, symlinks must point within it
+	root    string
+	filter  FolderFilter
+	ignore  *ignoreMatcher
+	entries []TarEntry
+}
+
+// walk collects the entries of a directory on disk, named after the given entry name. followed holds
+// the real paths of the directories being walked, to detect symlinks creating a loop.
+func (w *folderWalker) walk(dir string, dirName string, followed []string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Handle symlinks by following them to get the actual file
-		var actualInfo os.FileInfo
-		var actualPath string
-		if info.Mode()&os.ModeSymlink != 0 {
-			// This is a symlink, follow it
-			actualPath, err = os.Readlink(path)
-			if err != nil {
-				return fmt.Errorf("failed to read symlink %s: %w", path, err)
-			}
+		// Use relative paths in the archive (based on original path, not resolved path)
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := pathJoinName(dirName, filepath.ToSlash(relPath))
 
-			// If the symlink is relative, resolve it relative to the symlink's directory
-			if !filepath.IsAbs(actualPath) {
-				actualPath = filepath.Join(filepath.Dir(path), actualPath)
+		if name != "." {
+			if w.ignore.ignored(name, info.IsDir()) {
+				VerbosePrintf("Ignoring %s\n", name)
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
 			}
-
-			actualInfo, err = os.Stat(actualPath)
-			if err != nil {
-				return fmt.Errorf("failed to stat symlink target %s: %w", actualPath, err)
+			// The ignore files of nested directories apply to their content
+			if info.IsDir() {
+				if err := w.ignore.load(filepath.Join(path, IgnoreFileName), name, false); err != nil {
+					return err
+				}
 			}
-
-			// Only follow symlinks that point to regular files
-			if actualInfo.IsDir() {
-				return fmt.Errorf("symlink %s points to a directory, which is not supported", path)
+			if !info.IsDir() && w.filter.hasPatterns() {
+				selected, err := SelectedByPatterns(w.srcPath, filepath.Join(w.srcPath, filepath.FromSlash(name)), w.filter.Include, w.filter.Exclude)
+				if err != nil {
+					return err
+				}
+				if !selected {
+					VerbosePrintf("Skipping %s, not selected by the include and exclude patterns\n", name)
+					return nil
+				}
 			}
-		} else {
-			actualInfo = info
-			actualPath = path
 		}
 
-		// Create a tar header using the actual file info
-		header, err := tar.FileInfoHeader(actualInfo, actualInfo.Name())
+		if info.Mode()&os.ModeSymlink != 0 {
+			return w.addSymlink(path, name, followed)
+		}
+
+		w.entries = append(w.entries, TarEntry{
+			Name: name,
+			Path: path,
+			Info: info,
+		})
+		return nil
+	})
+}
+
+// addSymlink adds a symlink of the folder according to the symlink policy. Symlinks pointing outside of
+// the folder are always refused.
+func (w *folderWalker) addSymlink(path string, name string, followed []string) error {
+	policy := w.filter.Symlinks
+	if policy == "" {
+		policy = SymlinksFollow
+	}
+	if policy == SymlinksError {
+		return fmt.Errorf("folder holds the symlink %s, which is refused by the '%s' symlink policy", name, policy)
+	}
+
+	linkName, err := os.Readlink(path)
+	if err != nil {
+		return fmt.Errorf("failed to read symlink %s: %w", path, err)
+	}
+
+	// The real target must be within the folder. Preserved symlinks can dangle, but then
+	// their target is checked as written
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		resolved, err = filepath.Abs(resolved)
+	}
+	if err == nil && !isWithin(w.root, resolved) {
+		return fmt.Errorf("symlink %s to %s points outside of the folder", name, linkName)
+	}
+
+	if policy == SymlinksPreserve {
+		if err := checkSymlinkTarget(name, linkName, ""); err != nil {
+			return err
+		}
+		info, err := os.Lstat(path)
 		if err != nil {
 			return err
 		}
+		w.entries = append(w.entries, TarEntry{
+			Name: name,
+			Path: path,
+			Info: info,
+			Link: filepath.ToSlash(linkName),
+		})
+		return nil
+	}
 
-		// Use relative paths in the archive (based on original path, not resolved path)
-		relPath, err := filepath.Rel(srcPath, path)
+	// Follow the symlink, packaging the content it points to under the name of the symlink
+	if err != nil {
+		return fmt.Errorf("failed to follow symlink %s to %s: %w", name, linkName, err)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return fmt.Errorf("failed to stat symlink target %s: %w", resolved, err)
+	}
+	if !info.IsDir() {
+		w.entries = append(w.entries, TarEntry{
+			Name: name,
+			Path: resolved,
+			Info: info,
+		})
+		return nil
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err == nil {
+		parent, err = filepath.Abs(parent)
+	}
+	if err != nil {
+		return err
+	}
+	for _, dir := range append(followed, parent) {
+		if isWithin(resolved, dir) {
+			return fmt.Errorf("symlink %s to %s creates a loop", name, linkName)
+		}
+	}
+	return w.walk(resolved, name, append(followed, resolved))
+}
+
+// pathJoinName returns the name of an entry of a directory of the archive
+func pathJoinName(dirName string, rel string) string {
+	if dirName == "." {
+		return rel
+	}
+	if rel == "." {
+		return dirName
+	}
+	return dirName + "/" + rel
+}
+
+// withoutEmptyDirs removes the directories that do not hold any file, except for the root of the folder
+func withoutEmptyDirs(entries []TarEntry) []TarEntry {
+	nonEmpty := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Info.IsDir() {
+			continue
+		}
+		for dir := path.Dir(entry.Name); dir != "." && !nonEmpty[dir]; dir = path.Dir(dir) {
+			nonEmpty[dir] = true
+		}
+	}
+
+	var kept []TarEntry
+	for _, entry := range entries {
+		if entry.Info.IsDir() && entry.Name != "." && !nonEmpty[entry.Name] {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// TreeDigest computes the digest of a file tree from its entries: the sorted entry names, their type and
+// the digest of the file contents. It does not depend on the order, metadata or layout of the archives.
+// Only the files missing from the known digests are read.
+func TreeDigest(entries []TarEntry, known FileDigests) (digest.Digest, error) {
+	sorted := make([]TarEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	digester := digest.Canonical.Digester()
+	for _, entry := range sorted {
+		if entry.Info.IsDir() {
+			fmt.Fprintf(digester.Hash(), "dir %s\n", entry.Name)
+			continue
+		}
+		if entry.Link != "" {
+			fmt.Fprintf(digester.Hash(), "symlink %s %s\n", entry.Name, entry.Link)
+			continue
+		}
+
+		fileDigest, ok := known[entry.Path]
+		if !ok {
+			var err error
+			fileDigest, err = readFileDigest(entry.Path)
+			if err != nil {
+				return "", err
+			}
+		}
+		fmt.Fprintf(digester.Hash(), "file %s %s\n", entry.Name, fileDigest)
+	}
+	return digester.Digest(), nil
+}
+
+func readFileDigest(path string) (digest.Digest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return digest.Canonical.FromReader(file)
+}
+
+// WriteTarball writes the entries as a tarball to w. It returns the digest of the uncompressed tarball,
+// which is the diff id of a layer holding it.
+func WriteTarball(w io.Writer, entries []TarEntry, opts TarOptions) (digest.Digest, error) {
+	compressedWriter, err := NewCompressedWriter(w, opts.Compression)
+	if err != nil {
+		return "", err
+	}
+	digester := digest.Canonical.Digester()
+	tarWriter := tar.NewWriter(io.MultiWriter(compressedWriter, digester.Hash()))
+
+	if opts.Reproducible {
+		sorted := make([]TarEntry, len(entries))
+		copy(sorted, entries)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+		entries = sorted
+	}
+
+	for _, entry := range entries {
+		if err := writeTarEntry(tarWriter, entry, opts); err != nil {
+			return "", err
+		}
+	}
+
+	// Writers must be closed before the content is used, otherwise the tar
+	// footer and compression trailer are missing
+	if err := tarWriter.Close(); err != nil {
+		return "", err
+	}
+	if err := compressedWriter.Close(); err != nil {
+		return "", err
+	}
+	return digester.Digest(), nil
+}
+
+func writeTarEntry(tarWriter *tar.Writer, entry TarEntry, opts TarOptions) error {
+	// Create a tar header using the actual file info
+	header, err := tar.FileInfoHeader(entry.Info, entry.Link)
+	if err != nil {
+		return err
+	}
+	header.Name = entry.Name
+
+	if opts.Reproducible {
+		// Only keep the content and permissions, which do not depend on who pushes the folder nor when
+		header.Uid = 0
+		header.Gid = 0
+		header.Uname = ""
+		header.Gname = ""
+		header.ModTime = opts.ModTime
+		header.AccessTime = time.Time{}
+		header.ChangeTime = time.Time{}
+	}
+
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+
+	// If it's a regular file, write its content
+	if entry.Info.Mode().IsRegular() {
+		file, err := os.Open(entry.Path)
 		if err != nil {
 			return err
 		}
-		header.Name = filepath.ToSlash(relPath)
+		defer file.Close()
 
-		if err := tarWriter.WriteHeader(header); err != nil {
+		var content io.Writer = tarWriter
+		var fileDigester digest.Digester
+		if opts.FileDigests != nil {
+			fileDigester = digest.Canonical.Digester()
+			content = io.MultiWriter(tarWriter, fileDigester.Hash())
+		}
+		if _, err := io.Copy(content, file); err != nil {
 			return err
 		}
+		if fileDigester != nil {
+			opts.FileDigests[entry.Path] = fileDigester.Digest()
+		}
+	}
+	return nil
+}
 
-		// If it's a regular file, write its content
-		if !actualInfo.IsDir() {
-			file, err := os.Open(actualPath)
-			if err != nil {
-				return err
+// PartitionTarEntries splits the entries of a folder into one group per sub-path, in the order of the
+// sub-paths, followed by a group with the remaining entries. Each entry goes to the most specific
+// sub-path containing it. The remaining group is nil when it only holds the root folder.
+func PartitionTarEntries(entries []TarEntry, subPaths []string) ([][]TarEntry, error) {
+	groups := make([][]TarEntry, len(subPaths)+1)
+	remaining := len(subPaths)
+
+	for _, entry := range entries {
+		match := remaining
+		for i, subPath := range subPaths {
+			if entry.Name != subPath && !strings.HasPrefix(entry.Name, subPath+"/") {
+				continue
 			}
-			defer file.Close()
-			if _, err := io.Copy(tarWriter, file); err != nil {
-				return err
+			if match == remaining || len(subPath) > len(subPaths[match]) {
+				match = i
 			}
 		}
-		return nil
-	})
+		groups[match] = append(groups[match], entry)
+	}
 
-	if err != nil {
-		return nil, err
+	for i, subPath := range subPaths {
+		if len(groups[i]) == 0 {
+			return nil, fmt.Errorf("layer path %s not found in folder", subPath)
+		}
 	}
 
-	return buf.Bytes(), nil
+	if len(groups[remaining]) == 1 && groups[remaining][0].Name == "." {
+		groups[remaining] = nil
+	}
+	return groups, nil
+}
+
+// NormalizeSubPath cleans a sub-path of a folder (e.g. './workshop/' -> 'workshop'), making sure
+// it stays within the folder
+func NormalizeSubPath(subPath string) (string, error) {
+	cleaned := filepath.ToSlash(filepath.Clean(subPath))
+	cleaned = strings.TrimPrefix(cleaned, "/")
+	if cleaned == "." || cleaned == "" || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("invalid sub-path %s: it must be a path within the folder", subPath)
+	}
+	return cleaned, nil
 }
 
-// ExtractTarGz extracts a gzipped tarball from a reader to a destination directory.
-func ExtractTarGz(gzipStream io.Reader, dest string) error {
-	uncompressedStream, err := gzip.NewReader(gzipStream)
+// ExtractOptions tunes how a tarball is extracted. The zero value extracts every entry of the
+// tarball, detecting its compression from the magic bytes of the stream.
+type ExtractOptions struct {
+	// Compression of the tarball. When empty, it is detected from the magic bytes of the stream
+	Compression Compression
+	// Whiteouts applies the OCI/AUFS whiteout entries of image layers, removing the content extracted
+	// from the previous layers, instead of extracting them as regular files
+	Whiteouts bool
+	// Extracted records the paths extracted by the successive layers of an image. Whiteouts only remove
+	// the recorded paths, so the content the destination held before the extraction is kept
+	Extracted ExtractedPaths
+	// SubPath restricts the extraction to the entries under this path of the archive, which are
+	// extracted relative to it. The whole archive is extracted when empty
+	SubPath string
+	// SkipUnsupported skips the entries that cannot be extracted (devices, fifos, symlinks pointing
+	// outside of the destination, ...) instead of failing
+	SkipUnsupported bool
+}
+
+// ExtractTarball extracts a tarball from a reader to a destination directory.
+// Every call extracts a single layer, so when layers are extracted on top of each other the
+// whiteouts of a layer only remove the content of the previous ones.
+func ExtractTarball(stream io.Reader, dest string, opts ExtractOptions) error {
+	uncompressedStream, err := NewDecompressedReader(stream, opts.Compression)
 	if err != nil {
 		return err
 	}
 	defer uncompressedStream.Close()
 
+	subPath := ""
+	if opts.SubPath != "" {
+		subPath, err = NormalizeSubPath(opts.SubPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Entries extracted from this tarball, relative to the destination, so that opaque
+	// whiteouts only hide the content extracted from the previous layers
+	extracted := make(map[string]bool)
+	// Symlinks created by this tarball, verified once all of them exist
+	var symlinks []string
+
 	tarReader := tar.NewReader(uncompressedStream)
 
 	for {
@@ -109,20 +501,61 @@ func ExtractTarGz(gzipStream io.Reader, dest string) error {
 			return err
 		}
 
-		target := filepath.Join(dest, header.Name)
+		name, err := cleanEntryName(header.Name)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			// The root of the archive always exists
+			continue
+		}
+
+		if opts.Whiteouts {
+			dir, base := path.Split(name)
+			dir = strings.TrimSuffix(dir, "/")
+			if base == WhiteoutOpaqueDir {
+				if err := applyOpaqueWhiteout(dest, subPath, dir, opts.Extracted, extracted); err != nil {
+					return err
+				}
+				continue
+			}
+			if strings.HasPrefix(base, WhiteoutPrefix) {
+				if err := applyWhiteout(dest, subPath, path.Join(dir, strings.TrimPrefix(base, WhiteoutPrefix)), opts.Extracted); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		rel, ok := relativeToSubPath(name, subPath)
+		if !ok || rel == "" {
+			continue
+		}
+		// Symlinks extracted from previous entries are followed within the destination
+		target, err := resolveInDest(dest, rel)
+		if err != nil {
+			return err
+		}
+		// Only the content extracted by the previous entries or layers is replaced whatever it is
+		replace := opts.Extracted[rel] || extracted[rel]
 
 		switch header.Typeflag {
 		case tar.TypeDir:
+			// A directory can replace a file extracted from a previous layer
+			if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+				if err := os.Remove(target); err != nil {
+					return err
+				}
+			}
 			if err := os.MkdirAll(target, 0755); err != nil {
 				return err
 			}
 		case tar.TypeReg:
-			// Ensure parent directory exists
-			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			if err := prepareFileTarget(target, replace); err != nil {
 				return err
 			}
 
-			outFile, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
+			outFile, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(header.Mode))
 			if err != nil {
 				return err
 			}
@@ -131,9 +564,137 @@ func ExtractTarGz(gzipStream io.Reader, dest string) error {
 				return err
 			}
 			outFile.Close()
+		case tar.TypeLink:
+			linkName, err := cleanEntryName(header.Linkname)
+			if err != nil {
+				return err
+			}
+			linkRel, ok := relativeToSubPath(linkName, subPath)
+			if !ok || linkRel == "" {
+				VerbosePrintf("Skipping hard link %s to %s, outside of the extracted path\n", header.Name, header.Linkname)
+				continue
+			}
+			if err := prepareFileTarget(target, replace); err != nil {
+				return err
+			}
+			linkTarget, err := resolveInDest(dest, linkRel)
+			if err != nil {
+				return err
+			}
+			if err := os.Link(linkTarget, target); err != nil {
+				return fmt.Errorf("failed to create hard link %s: %w", header.Name, err)
+			}
+		case tar.TypeSymlink:
+			if err := checkSymlinkTarget(name, header.Linkname, subPath); err != nil {
+				if opts.SkipUnsupported {
+					VerbosePrintf("Skipping %v\n", err)
+					continue
+				}
+				return err
+			}
+			if err := prepareFileTarget(target, replace); err != nil {
+				return err
+			}
+			if err := os.Symlink(filepath.FromSlash(header.Linkname), target); err != nil {
+				return fmt.Errorf("failed to create symlink %s: %w", header.Name, err)
+			}
+			symlinks = append(symlinks, target)
 		default:
+			if opts.SkipUnsupported {
+				VerbosePrintf("Skipping unsupported entry %s of type %c\n", header.Name, header.Typeflag)
+				continue
+			}
 			return fmt.Errorf("unsupported file type in tar: %c", header.Typeflag)
 		}
+		extracted[rel] = true
+	}
+
+	if opts.Extracted != nil {
+		for rel := range extracted {
+			opts.Extracted[rel] = true
+		}
+	}
+	return verifySymlinks(dest, symlinks, opts.SkipUnsupported)
+}
+
+// ExtractFile writes the content of a reader as the file of the given name, relative to the destination
+// directory, e.g. an OCI layer holding a single file. Names escaping the destination are refused, symlinks
+// of the parent directories never lead outside of it, and files outside of the extracted sub-path are skipped.
+func ExtractFile(stream io.Reader, dest string, name string, opts ExtractOptions) error {
+	cleaned, err := cleanEntryName(name)
+	if err != nil {
+		return err
+	}
+	if cleaned == "" {
+		return fmt.Errorf("invalid file name: %s", name)
+	}
+
+	subPath := ""
+	if opts.SubPath != "" {
+		subPath, err = NormalizeSubPath(opts.SubPath)
+		if err != nil {
+			return err
+		}
+	}
+	rel, ok := relativeToSubPath(cleaned, subPath)
+	if !ok || rel == "" {
+		return nil
+	}
+
+	// Symlinks extracted from previous layers are followed within the destination
+	target, err := resolveInDest(dest, rel)
+	if err != nil {
+		return err
+	}
+	if err := prepareFileTarget(target, opts.Extracted[rel]); err != nil {
+		return err
+	}
+	outFile, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	if _, err := io.Copy(outFile, stream); err != nil {
+		return err
+	}
+	return outFile.Close()
+}
+
+// cleanEntryName returns the name of a tar entry relative to the root of the archive, using forward
+// slashes. Image layers may use absolute names or names starting with './'. Entries escaping the
+// root of the archive are refused.
+func cleanEntryName(name string) (string, error) {
+	cleaned := path.Clean(strings.TrimPrefix(filepath.ToSlash(name), "/"))
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("tar entry %s escapes the destination folder", name)
+	}
+	if cleaned == "." {
+		return "", nil
+	}
+	return cleaned, nil
+}
+
+// prepareFileTarget creates the parent directory of a file and removes what the destination holds at the
+// same path. Content extracted by the previous entries or layers is removed whatever it is, when extracted
+// is true. Other content is only replaced when it is a file or an empty directory, so that extracting
+// never deletes a folder the destination already held.
+func prepareFileTarget(target string, extracted bool) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	info, err := os.Lstat(target)
+	if err != nil {
+		return nil
+	}
+	// Files are replaced rather than truncated, so that content hard linked to them is left untouched
+	if extracted {
+		return os.RemoveAll(target)
+	}
+	if err := os.Remove(target); err != nil {
+		if info.IsDir() {
+			return fmt.Errorf("cannot replace directory %s with a file: the directory is not empty", target)
+		}
+		return err
 	}
 	return nil
 }
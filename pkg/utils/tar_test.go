@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestWriteTarballDigests(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"a": "one", "sub/b": "two"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(src, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := CollectTarEntries(src, FolderFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		compression Compression
+	}{
+		{name: "gzip", compression: CompressionGzip},
+		{name: "uncompressed", compression: CompressionNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileDigests := make(FileDigests)
+			var buf bytes.Buffer
+			diffID, err := WriteTarball(&buf, entries, TarOptions{Compression: tt.compression, FileDigests: fileDigests})
+			if err != nil {
+				t.Fatalf("WriteTarball() error = %v", err)
+			}
+
+			var uncompressed io.Reader = &buf
+			if tt.compression == CompressionGzip {
+				if uncompressed, err = gzip.NewReader(&buf); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := digest.Canonical.FromReader(uncompressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diffID != want {
+				t.Errorf("WriteTarball() diff id = %s, want %s", diffID, want)
+			}
+
+			for name, content := range files {
+				path := filepath.Join(src, filepath.FromSlash(name))
+				if got := fileDigests[path]; got != digest.FromString(content) {
+					t.Errorf("file digest of %s = %s, want %s", name, got, digest.FromString(content))
+				}
+			}
+
+			// The recorded digests give the same tree digest as reading the files
+			read, err := TreeDigest(entries, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorded, err := TreeDigest(entries, fileDigests)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recorded != read {
+				t.Errorf("TreeDigest() with recorded digests = %s, want %s", recorded, read)
+			}
+		})
+	}
+}
+
+func TestExtractFileThroughSymlinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		linkName string
+		file     string
+		want     string
+	}{
+		{name: "relative symlink", linkName: "usr/lib", file: "lib/x", want: "usr/lib/x"},
+		{name: "absolute symlink", linkName: "/etc", file: "lib/x", want: "etc/x"},
+		{name: "symlink escaping the destination", linkName: "../../..", file: "lib/x", want: "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			if err := os.Symlink(filepath.FromSlash(tt.linkName), filepath.Join(dest, "lib")); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := ExtractFile(bytes.NewReader([]byte("content")), dest, tt.file, ExtractOptions{}); err != nil {
+				t.Fatalf("ExtractFile() error = %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(tt.want)))
+			if err != nil {
+				t.Fatalf("file not extracted to %s: %v", tt.want, err)
+			}
+			if string(content) != "content" {
+				t.Errorf("extracted content = %q, want %q", content, "content")
+			}
+		})
+	}
+}
+
+// testFileInfo is the os.FileInfo of the entries built by testEntries
+type testFileInfo struct {
+	os.FileInfo
+	dir bool
+}
+
+func (i testFileInfo) IsDir() bool { return i.dir }
+
+// testEntries builds tar entries from names: names ending with '/' are directories, the others are files
+func testEntries(names ...string) []TarEntry {
+	var entries []TarEntry
+	for _, name := range names {
+		entries = append(entries, TarEntry{Name: strings.TrimSuffix(name, "/"), Info: testFileInfo{dir: strings.HasSuffix(name, "/")}})
+	}
+	return entries
+}
+
+func TestPartitionTarEntries(t *testing.T) {
+	entries := testEntries("./", "docs/", "docs/a", "docs/api/", "docs/api/b", "src/", "src/c", "README")
+
+	tests := []struct {
+		name     string
+		subPaths []string
+		want     [][]string
+		wantErr  bool
+	}{
+		{
+			name: "whole folder",
+			want: [][]string{{".", "docs", "docs/a", "docs/api", "docs/api/b", "src", "src/c", "README"}},
+		},
+		{
+			name:     "one layer path",
+			subPaths: []string{"src"},
+			want:     [][]string{{"src", "src/c"}, {".", "docs", "docs/a", "docs/api", "docs/api/b", "README"}},
+		},
+		{
+			name:     "most specific layer path",
+			subPaths: []string{"docs", "docs/api"},
+			want:     [][]string{{"docs", "docs/a"}, {"docs/api", "docs/api/b"}, {".", "src", "src/c", "README"}},
+		},
+		{
+			name:     "no remaining content",
+			subPaths: []string{"docs", "src", "README"},
+			want:     [][]string{{"docs", "docs/a", "docs/api", "docs/api/b"}, {"src", "src/c"}, {"README"}, nil},
+		},
+		{
+			name:     "prefix that is not a parent",
+			subPaths: []string{"doc"},
+			wantErr:  true,
+		},
+		{
+			name:     "missing layer path",
+			subPaths: []string{"missing"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups, err := PartitionTarEntries(entries, tt.subPaths)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PartitionTarEntries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got [][]string
+			for _, group := range groups {
+				var names []string
+				for _, entry := range group {
+					names = append(names, entry.Name)
+				}
+				got = append(got, names)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PartitionTarEntries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractTarballExistingDirectory(t *testing.T) {
+	tests := []struct {
+		name string
+		// Files the destination holds before the extraction, directories ending with '/'
+		existing []string
+		layers   [][]string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "file replacing a directory of the destination",
+			existing: []string{"a/mine"},
+			layers:   [][]string{{"a"}},
+			want:     []string{"a/", "a/mine"},
+			wantErr:  true,
+		},
+		{
+			name:     "file replacing an empty directory of the destination",
+			existing: []string{"a/"},
+			layers:   [][]string{{"a"}},
+			want:     []string{"a"},
+		},
+		{
+			name:     "file replacing a file of the destination",
+			existing: []string{"a"},
+			layers:   [][]string{{"a"}},
+			want:     []string{"a"},
+		},
+		{
+			name:   "file replacing a directory of a previous layer",
+			layers: [][]string{{"a/", "a/x"}, {"a"}},
+			want:   []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			for _, name := range tt.existing {
+				target := filepath.Join(dest, filepath.FromSlash(name))
+				if strings.HasSuffix(name, "/") {
+					if err := os.MkdirAll(target, 0755); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(target, []byte("mine"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			opts := ExtractOptions{Compression: CompressionNone, Extracted: make(ExtractedPaths)}
+			var err error
+			for _, layer := range tt.layers {
+				if err = ExtractTarball(testLayer(t, layer...), dest, opts); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractTarball() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := listFiles(t, dest); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("extracted %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
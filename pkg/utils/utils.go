@@ -2,25 +2,44 @@ package utils
 
 import (
 	"fmt"
-	"runtime"
 	"strings"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
-const DefaultPlatforms = "linux/amd64, linux/arm64"
-
-const SupportedPlatforms = "linux/amd64, linux/arm64, darwin/amd64, darwin/arm64"
-
+// ValidatePlatforms checks that every platform is well formed and supported. A supported platform without
+// variant accepts every variant of its architecture.
 func ValidatePlatforms(platforms []string) error {
-	for _, platform := range platforms {
-		if !strings.Contains(SupportedPlatforms, platform) {
-			return fmt.Errorf("unsupported platform: %s", platform)
+	for _, platformStr := range platforms {
+		platform, err := NewPlatform(platformStr)
+		if err != nil {
+			return err
+		}
+		if !isSupported(platform) {
+			return fmt.Errorf("unsupported platform: %s (supported: %s)", platformStr, joinPlatforms(supportedPlatforms))
 		}
 	}
 	return nil
 }
 
+func isSupported(platform Platform) bool {
+	for _, supported := range supportedPlatforms {
+		if supported.OS == platform.OS && supported.Architecture == platform.Architecture &&
+			(supported.Variant == "" || supported.Variant == platform.Variant) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinPlatforms(platforms []Platform) string {
+	platformStrs := make([]string, 0, len(platforms))
+	for _, platform := range platforms {
+		platformStrs = append(platformStrs, platform.String())
+	}
+	return strings.Join(platformStrs, ", ")
+}
+
 // SlicePlatforms converts a comma-separated string into a slice of OCI Platform structs.
 func SlicePlatforms(platformStr string) []string {
 	if platformStr == "" {
@@ -38,21 +57,18 @@ func SlicePlatforms(platformStr string) []string {
 }
 
 /**
- * Parse the provided platform and set the target platform
+ * Parse and normalize the provided platform and set the target platform
  *
  * @param targetPlatform - The target platform to set
- * @param platformStr - The platform string to parse
+ * @param platformStr - The platform string to parse (os[(osversion)]/arch[/variant])
  * @return error - An error if the platform string is invalid
  */
 func ParsePlatform(targetPlatform *ocispec.Platform, platformStr string) error {
-	osArch := strings.Split(platformStr, "/")
-	if len(osArch) != 2 {
-		return fmt.Errorf("invalid platform format: %s (expected format: os/arch)", platformStr)
-	}
-	*targetPlatform = ocispec.Platform{
-		OS:           osArch[0],
-		Architecture: osArch[1],
+	platform, err := NewPlatform(platformStr)
+	if err != nil {
+		return err
 	}
+	*targetPlatform = platform.OCI()
 	return nil
 }
 
@@ -66,5 +82,5 @@ func GetTagFromRef(ref string) string {
 }
 
 func GetOSPlatformStr() string {
-	return fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)
+	return HostPlatform().String()
 }
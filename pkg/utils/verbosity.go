@@ -1,31 +1,43 @@
 package utils
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"os"
+)
 
 var Verbose bool
 
+// verboseOutput receives the verbose messages
+var verboseOutput io.Writer = os.Stdout
+
 // SetVerbose sets the global verbosity level
 func SetVerbose(v bool) {
 	Verbose = v
 }
 
+// SetVerboseOutput sets the writer receiving the verbose messages, the standard output by default
+func SetVerboseOutput(w io.Writer) {
+	verboseOutput = w
+}
+
 // VerbosePrintf prints formatted output only if verbose mode is enabled
 func VerbosePrintf(format string, args ...interface{}) {
 	if Verbose {
-		fmt.Printf(format, args...)
+		fmt.Fprintf(verboseOutput, format, args...)
 	}
 }
 
 // VerbosePrintln prints output only if verbose mode is enabled
 func VerbosePrintln(args ...interface{}) {
 	if Verbose {
-		fmt.Println(args...)
+		fmt.Fprintln(verboseOutput, args...)
 	}
 }
 
 // VerbosePrint prints output only if verbose mode is enabled
 func VerbosePrint(args ...interface{}) {
 	if Verbose {
-		fmt.Print(args...)
+		fmt.Fprint(verboseOutput, args...)
 	}
 }
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	// WhiteoutPrefix prefixes the name of an entry removed by an image layer (OCI and AUFS whiteouts)
+	WhiteoutPrefix = ".wh."
+	// WhiteoutOpaqueDir marks a directory whose content from the previous layers is hidden
+	WhiteoutOpaqueDir = WhiteoutPrefix + WhiteoutPrefix + ".opq"
+)
+
+// ExtractedPaths records the paths extracted by the layers of an image, relative to the destination and
+// using forward slashes, so that the whiteouts of a layer only remove the content of the previous layers
+type ExtractedPaths map[string]bool
+
+// applyWhiteout removes the content extracted from the previous layers for the given archive path. When the
+// archive path is the extracted sub-path or one of its parents, all the content of the previous layers is
+// removed. The content the destination held before the extraction is kept.
+func applyWhiteout(dest string, subPath string, name string, previous ExtractedPaths) error {
+	rel, ok := whiteoutTarget(name, subPath)
+	if !ok {
+		return nil
+	}
+	VerbosePrintf("Removing whited out %s\n", name)
+	return removeExtracted(dest, previous, func(p string) bool {
+		return rel == "" || p == rel || strings.HasPrefix(p, rel+"/")
+	})
+}
+
+// applyOpaqueWhiteout removes the content of a directory extracted from the previous layers, keeping the
+// entries extracted from the current layer and the content the destination held before the extraction
+func applyOpaqueWhiteout(dest string, subPath string, dir string, previous ExtractedPaths, current map[string]bool) error {
+	rel, ok := whiteoutTarget(dir, subPath)
+	if !ok {
+		return nil
+	}
+	VerbosePrintf("Clearing content of %s from previous layers\n", dir)
+	return removeExtracted(dest, previous, func(p string) bool {
+		return (rel == "" || strings.HasPrefix(p, rel+"/")) && !current[p]
+	})
+}
+
+// whiteoutTarget returns the path removed by a whiteout of the archive path, relative to the destination,
+// and whether it is extracted. It is empty when the archive path is the sub-path or one of its parents.
+func whiteoutTarget(name string, subPath string) (string, bool) {
+	if rel, ok := relativeToSubPath(name, subPath); ok {
+		return rel, true
+	}
+	return "", isParentPath(name, subPath)
+}
+
+// removeExtracted removes the paths extracted by the previous layers that match, children first.
+// Directories are only removed once empty, as they can hold content that was not extracted.
+func removeExtracted(dest string, previous ExtractedPaths, match func(string) bool) error {
+	var paths []string
+	for p := range previous {
+		if match(p) {
+			paths = append(paths, p)
+		}
+	}
+	// The children of a directory sort after it
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+
+	for _, p := range paths {
+		target, err := resolveInDest(dest, p)
+		if err != nil {
+			return err
+		}
+		info, err := os.Lstat(target)
+		if os.IsNotExist(err) {
+			delete(previous, p)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if entries, err := os.ReadDir(target); err != nil || len(entries) > 0 {
+				continue
+			}
+		}
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+		delete(previous, p)
+	}
+	return nil
+}
+
+// relativeToSubPath returns the path of an archive entry relative to the extracted sub-path, and whether
+// the entry is within it. Without sub-path every entry is extracted as is.
+func relativeToSubPath(name string, subPath string) (string, bool) {
+	switch {
+	case subPath == "":
+		return name, true
+	case name == subPath:
+		return "", true
+	case strings.HasPrefix(name, subPath+"/"):
+		return strings.TrimPrefix(name, subPath+"/"), true
+	default:
+		return "", false
+	}
+}
+
+// isParentPath reports whether the archive path is a parent directory of the sub-path
+func isParentPath(name string, subPath string) bool {
+	if subPath == "" {
+		return false
+	}
+	return name == "" || strings.HasPrefix(subPath, name+"/")
+}
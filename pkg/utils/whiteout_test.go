@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testLayer builds an uncompressed layer from entry names: names ending with '/' are directories,
+// the others are files holding their own name
+func testLayer(t *testing.T, names ...string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range names {
+		header := &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(name))}
+		if strings.HasSuffix(name, "/") {
+			header = &tar.Header{Name: name, Typeflag: tar.TypeDir, Mode: 0755}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+// listFiles returns the files and directories of a folder, relative to it, directories ending with '/'
+func listFiles(t *testing.T, root string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil || p == root {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			rel += "/"
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestExtractTarballWhiteouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		subPath string
+		// Files the destination holds before the extraction
+		existing []string
+		layers   [][]string
+		want     []string
+	}{
+		{
+			name:   "whiteout of a file",
+			layers: [][]string{{"etc/", "etc/a", "etc/b"}, {"etc/.wh.a"}},
+			want:   []string{"etc/", "etc/b"},
+		},
+		{
+			name:   "whiteout of a directory",
+			layers: [][]string{{"opt/", "opt/app/", "opt/app/x", "keep"}, {"opt/.wh.app"}},
+			want:   []string{"keep", "opt/"},
+		},
+		{
+			name:   "opaque whiteout keeps the entries of its layer",
+			layers: [][]string{{"opt/", "opt/a", "opt/b"}, {"opt/.wh..wh..opq", "opt/c"}},
+			want:   []string{"opt/", "opt/c"},
+		},
+		{
+			name:   "whiteout of a file of the same layer",
+			layers: [][]string{{"a"}, {"b", ".wh.b"}},
+			want:   []string{"a", "b"},
+		},
+		{
+			name:     "whiteout keeps existing files",
+			existing: []string{"etc/a", "mine"},
+			layers:   [][]string{{"etc/", "etc/b"}, {".wh.etc", ".wh.mine"}},
+			want:     []string{"etc/", "etc/a", "mine"},
+		},
+		{
+			name:     "opaque whiteout of a parent of the sub-path keeps existing files",
+			subPath:  "opt/app/docs",
+			existing: []string{"mine"},
+			layers:   [][]string{{"opt/app/docs/", "opt/app/docs/a"}, {"opt/.wh..wh..opq", "opt/app/docs/b"}},
+			want:     []string{"b", "mine"},
+		},
+		{
+			name:     "whiteout of a parent of the sub-path keeps existing files",
+			subPath:  "opt/app",
+			existing: []string{"mine"},
+			layers:   [][]string{{"opt/app/", "opt/app/a"}, {".wh.opt"}},
+			want:     []string{"mine"},
+		},
+		{
+			name:    "whiteout outside of the sub-path",
+			subPath: "opt",
+			layers:  [][]string{{"opt/a", "etc/a"}, {"etc/.wh.a", ".wh.etc"}},
+			want:    []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			for _, name := range tt.existing {
+				target := filepath.Join(dest, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(target, []byte(name), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			opts := ExtractOptions{
+				Compression: CompressionNone,
+				Whiteouts:   true,
+				Extracted:   make(ExtractedPaths),
+				SubPath:     tt.subPath,
+			}
+			for _, layer := range tt.layers {
+				if err := ExtractTarball(testLayer(t, layer...), dest, opts); err != nil {
+					t.Fatalf("ExtractTarball() error = %v", err)
+				}
+			}
+
+			if got := listFiles(t, dest); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("extracted %v, want %v", got, tt.want)
+			}
+		})
+	}
+}